@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Namespace of tag sets below the cache prefix
+const tagNamespace = "tag:"
+
+// Marks tag sets that are being flushed, see Backend.Flush
+const drainingMarker = ":draining:"
+
+// PrivateNamespace holds values which are not a copy of other data, such as
+// the idempotency records of requests, Inspect and Purge refuse its keys
+const PrivateNamespace = "private:"
+
+var (
+	// ErrEmptyPattern is returned by Purge for an empty pattern
+	ErrEmptyPattern = errors.New("cache: purge pattern is required")
+	// ErrInternalKey is returned by Inspect and Purge for keys which are not cached entries
+	ErrInternalKey = errors.New("cache: key is not a cached entry")
+)
+
+// Namespaces of the keys Inspect and Purge refuse, tags are managed with Tags and Flush
+var internalNamespaces = []string{tagNamespace, lockNamespace, PrivateNamespace}
+
+// internal reports whether a key or pattern below the prefix is in an internal namespace
+func internal(key string) bool {
+	for _, namespace := range internalNamespaces {
+		if strings.HasPrefix(key, namespace) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type TagInfo struct {
+	Tag  string `json:"tag"`
+	Keys int64  `json:"keys"`
+}
+
+type KeyInfo struct {
+	Key string `json:"key"`
+	// Seconds to live, -1 when the key never expires
+	TTL   float64     `json:"ttl"`
+	Size  int         `json:"size"`
+	Value interface{} `json:"value"`
+}
+
+// Tags lists every tag with the number of keys it indexes
+func (c *Cache) Tags(ctx context.Context) ([]TagInfo, error) {
+	ctx, span := tracer.Start(ctx, "CacheTags")
+	defer span.End()
+
+	prefix := c.namespace(tagNamespace)
+	var tags []TagInfo
+	err := c.backend.Scan(ctx, prefix+"*", func(keys []string) error {
+		for _, key := range keys {
+			if strings.Contains(key, drainingMarker) {
+				continue
+			}
+			count, err := c.backend.Count(ctx, key)
+			if err != nil {
+				return err
+			}
+			tag := strings.TrimPrefix(key, prefix)
+			if c.colocate {
+				tag = strings.TrimSuffix(strings.TrimPrefix(tag, "{"), "}")
+			}
+			tags = append(tags, TagInfo{Tag: tag, Keys: count})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+
+	return tags, nil
+}
+
+// Inspect returns the TTL and stored value of a key, ErrMiss when it does not exist
+func (c *Cache) Inspect(ctx context.Context, key string) (*KeyInfo, error) {
+	ctx, span := tracer.Start(ctx, "CacheInspect")
+	defer span.End()
+
+	if internal(key) {
+		return nil, ErrInternalKey
+	}
+
+	fullKey := c.key(key)
+	ttl, err := c.backend.TTL(ctx, fullKey)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := c.backend.Get(ctx, fullKey)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &KeyInfo{Key: key, TTL: -1, Size: len(raw)}
+	if ttl > 0 {
+		info.TTL = ttl.Round(time.Millisecond).Seconds()
+	}
+
+	codec, payload, err := c.unwrap(raw)
+	if err != nil {
+		return nil, err
+	}
+	if codec.format() == formatJSON && json.Valid(payload) {
+		info.Value = json.RawMessage(payload)
+	} else if err := codec.Unmarshal(payload, &info.Value); err != nil {
+		// Show what is stored rather than failing the inspection
+		info.Value = string(payload)
+	}
+
+	return info, nil
+}
+
+// Purge deletes every cached entry matching the glob-style pattern and
+// returns how many were deleted. Keys in the internal namespaces are kept,
+// so tag sets still find their keys and locks and private values survive.
+func (c *Cache) Purge(ctx context.Context, pattern string) (int, error) {
+	ctx, span := tracer.Start(ctx, "CachePurge")
+	defer span.End()
+
+	if pattern == "" {
+		return 0, ErrEmptyPattern
+	}
+	if internal(pattern) {
+		return 0, ErrInternalKey
+	}
+
+	prefix := c.namespace("")
+	purged := 0
+	err := c.backend.Scan(ctx, c.keyPattern(pattern), func(keys []string) error {
+		batch := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if !internal(strings.TrimPrefix(key, prefix)) {
+				batch = append(batch, key)
+			}
+		}
+
+		if err := c.backend.Delete(ctx, batch...); err != nil {
+			return err
+		}
+		c.publish(ctx, batch...)
+		purged += len(batch)
+
+		return nil
+	})
+
+	return purged, err
+}
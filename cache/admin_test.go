@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestTagsCountsKeys(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := cacher.Tag("users").Set(ctx, fmt.Sprintf("user_%d", i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cacher.Tag("plans", "users").Set(ctx, "plan_1", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := cacher.Tags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TagInfo{{Tag: "plans", Keys: 1}, {Tag: "users", Keys: 4}}
+	if fmt.Sprint(tags) != fmt.Sprint(want) {
+		t.Fatalf("tags = %v, want %v", tags, want)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+
+	if err := cacher.Set(ctx, "user_1", map[string]string{"name": "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := cacher.Inspect(ctx, "user_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.TTL <= 0 || info.TTL > 60 {
+		t.Fatalf("ttl = %v, want within the minute default", info.TTL)
+	}
+	if raw, ok := info.Value.(json.RawMessage); !ok || string(raw) != `{"name":"alice"}` {
+		t.Fatalf("value = %v", info.Value)
+	}
+
+	if _, err := cacher.Inspect(ctx, "missing"); err != ErrMiss {
+		t.Fatalf("err = %v, want ErrMiss", err)
+	}
+	if _, err := cacher.Inspect(ctx, PrivateNamespace+"token"); err != ErrInternalKey {
+		t.Fatalf("err = %v, want ErrInternalKey", err)
+	}
+}
+
+func TestPurgeKeepsTagSets(t *testing.T) {
+	cacher, server := newTestCache(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := cacher.Tag("users").Set(ctx, fmt.Sprintf("GetUser_%d", i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cacher.Set(ctx, "GetPlans", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := cacher.Purge(ctx, "GetUser_*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 5 {
+		t.Fatalf("purged = %d, want 5", purged)
+	}
+	if !server.Exists("test:GetPlans") {
+		t.Fatal("key outside the pattern was purged")
+	}
+
+	// Wildcards never remove the tag index or private values
+	if err := cacher.Set(ctx, PrivateNamespace+"token", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cacher.Purge(ctx, "*"); err != nil {
+		t.Fatal(err)
+	}
+	if !server.Exists("test:tag:users") {
+		t.Fatal("tag set was purged")
+	}
+	if !server.Exists("test:" + PrivateNamespace + "token") {
+		t.Fatal("private value was purged")
+	}
+
+	for _, pattern := range []string{"tag:*", "lock:*", PrivateNamespace + "*"} {
+		if _, err := cacher.Purge(ctx, pattern); err != ErrInternalKey {
+			t.Fatalf("%s: err = %v, want ErrInternalKey", pattern, err)
+		}
+	}
+
+	if _, err := cacher.Purge(ctx, ""); err != ErrEmptyPattern {
+		t.Fatalf("err = %v, want ErrEmptyPattern", err)
+	}
+}
+
+func TestStatsCountHitsAndMisses(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+
+	var val *int
+	if err := cacher.Get(ctx, "key", &val); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacher.Set(ctx, "key", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacher.Tag("numbers").Get(ctx, "key", &val); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := cacher.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Sets != 1 || stats.HitRatio != 0.5 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
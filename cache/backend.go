@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/go-redis/redis/v8"
+)
+
+// Cache drivers selected with CACHE_DRIVER
+const (
+	DriverRedis    = "redis"
+	DriverCluster  = "cluster"
+	DriverSentinel = "sentinel"
+	DriverMemory   = "memory"
+)
+
+// ErrMiss is returned by Backend.Get when the key does not exist
+var ErrMiss = errors.New("cache: miss")
+
+// Backend stores encoded cache values and the tag sets that index them.
+// Keys passed in are already prefixed by the Cache.
+type Backend interface {
+	Ping(ctx context.Context) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set writes the value and adds the key to every tag set
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tagKeys []string) error
+	// Flush deletes every key in the tag sets and the sets themselves,
+	// calling flushed with each batch of deleted keys
+	Flush(ctx context.Context, tagKeys []string, flushed func(keys []string)) error
+	// Scan calls fn with batches of keys matching a glob-style pattern
+	Scan(ctx context.Context, match string, fn func(keys []string) error) error
+	// Count returns the number of keys in a tag set
+	Count(ctx context.Context, tagKey string) (int64, error)
+	// TTL returns the time to live of a key, -1 when it never expires and
+	// ErrMiss when it does not exist
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Delete(ctx context.Context, keys ...string) error
+	// SetNX writes the value only when the key does not exist
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// CompareAndDelete deletes the key only while it holds value
+	CompareAndDelete(ctx context.Context, key string, value []byte) (bool, error)
+	// CompareAndExpire sets a new TTL only while the key holds value
+	CompareAndExpire(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe calls handle for every message until the returned Closer is
+	// closed, reset is called whenever messages may have been missed
+	Subscribe(channel string, handle func(payload []byte), reset func()) io.Closer
+	Close() error
+}
+
+// colocator is implemented by backends that shard keys by hash slot, keys
+// then carry a hash tag so the keys of an entity stay in one slot
+type colocator interface {
+	colocate() bool
+}
+
+// Initialize connects the Redis client for the configured driver. The memory
+// driver does not need Redis, it returns a single node client for the
+// services that use Redis directly only when REDIS_ADDR is set, nil otherwise
+func Initialize() redis.UniversalClient {
+	switch config.AppConfig.CacheDriver {
+	case DriverMemory:
+		if config.AppConfig.RedisAddr == "" {
+			return nil
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:     config.AppConfig.RedisAddr,
+			Username: config.AppConfig.RedisUsername,
+			Password: config.AppConfig.RedisPassword,
+		})
+	case DriverCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    config.AppConfig.RedisAddrs,
+			Username: config.AppConfig.RedisUsername,
+			Password: config.AppConfig.RedisPassword,
+		})
+	case DriverSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.AppConfig.RedisMasterName,
+			SentinelAddrs:    config.AppConfig.RedisAddrs,
+			SentinelPassword: config.AppConfig.RedisSentinelPassword,
+			Username:         config.AppConfig.RedisUsername,
+			Password:         config.AppConfig.RedisPassword,
+		})
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:     config.AppConfig.RedisAddr,
+			Username: config.AppConfig.RedisUsername,
+			Password: config.AppConfig.RedisPassword,
+		})
+	}
+}
+
+// NewBackend returns the cache backend for the configured driver
+func NewBackend(client redis.UniversalClient) Backend {
+	if config.AppConfig.CacheDriver == DriverMemory {
+		return NewMemoryBackend()
+	}
+
+	return NewRedisBackend(client)
+}
@@ -0,0 +1,377 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils/color"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache is safe for concurrent use. Tag returns a new tagged view,
+// it never modifies the Cache it is called on.
+type Cache struct {
+	backend Backend
+	tags    []string
+
+	prefix string
+	// Keys carry a hash tag so the keys of an entity share a cluster slot
+	colocate bool
+	expired  time.Duration
+	beta     float64
+	// Values are encoded with codec and compressed from compressAbove bytes
+	codec         Codec
+	compressAbove int
+	// Shared by every tagged view so Remember collapses misses cache-wide
+	group   *singleflight.Group
+	metrics *metrics
+
+	// Optional in-process tier, nil when disabled
+	local      *local
+	instanceID string
+	subscriber io.Closer
+}
+
+func NewCache(backend Backend, opts ...Option) *Cache {
+	err := backend.Ping(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	if !fiber.IsChild() {
+		log.Println("Cache client connected", color.Format(color.GREEN, "successfully!"))
+	}
+
+	o := &Options{codec: GoJSON}
+	for _, opt := range opts {
+		opt(o)
+	}
+	// The cache keeps its keys apart from the other keys in Redis, so
+	// Purge never reaches them
+	if o.prefix == "" {
+		o.prefix = "cache"
+	}
+
+	cb, ok := backend.(colocator)
+
+	c := &Cache{
+		backend:       backend,
+		prefix:        o.prefix,
+		colocate:      ok && cb.colocate(),
+		expired:       o.expired,
+		beta:          o.beta,
+		codec:         o.codec,
+		compressAbove: o.compressAbove,
+		group:         &singleflight.Group{},
+		metrics:       newMetrics(),
+	}
+
+	if o.localMaxBytes > 0 {
+		c.local = newLocal(o.localMaxBytes, o.localTTL)
+		c.instanceID = newID()
+		c.subscriber = backend.Subscribe(c.invalidationChannel(), c.invalidate, c.local.purge)
+	}
+
+	return c
+}
+
+// newID returns a random hex identifier
+func newID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(id)
+}
+
+type Options struct {
+	prefix  string
+	expired time.Duration
+	beta    float64
+
+	localMaxBytes int64
+	localTTL      time.Duration
+
+	codec         Codec
+	compressAbove int
+}
+
+type Option func(*Options)
+
+func WithPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.prefix = prefix
+	}
+}
+
+func WithExpired(exp time.Duration) Option {
+	return func(o *Options) {
+		o.expired = exp
+	}
+}
+
+// WithEarlyRefresh enables probabilistic early refresh in Remember,
+// beta > 1 favours earlier refreshes and 0 disables it
+func WithEarlyRefresh(beta float64) Option {
+	return func(o *Options) {
+		o.beta = beta
+	}
+}
+
+// WithLocal keeps up to maxBytes of values in process for at most ttl in
+// front of Redis, flushed keys are evicted on every instance via pub/sub
+func WithLocal(maxBytes int64, ttl time.Duration) Option {
+	return func(o *Options) {
+		o.localMaxBytes = maxBytes
+		o.localTTL = ttl
+	}
+}
+
+// WithCodec sets how values are serialized, entries written with another
+// codec stay readable
+func WithCodec(codec Codec) Option {
+	return func(o *Options) {
+		o.codec = codec
+	}
+}
+
+// WithCompression compresses values of at least threshold bytes with zstd,
+// 0 disables compression
+func WithCompression(threshold int) Option {
+	return func(o *Options) {
+		o.compressAbove = threshold
+	}
+}
+
+// Tag returns a copy of the cache whose Set and Flush apply to the tags
+func (c *Cache) Tag(tag ...string) *Cache {
+	tagged := *c
+	tagged.tags = append([]string(nil), tag...)
+
+	return &tagged
+}
+
+// Close stops the invalidation subscriber and closes the backend
+func (c *Cache) Close() error {
+	if c.subscriber != nil {
+		c.subscriber.Close()
+	}
+
+	return c.backend.Close()
+}
+
+func (c *Cache) key(key string) string {
+	if c.colocate {
+		key = hashTag(key)
+	}
+
+	return c.namespace(key)
+}
+
+// Namespaces whose keys are hashed by the name after the namespace
+var hashedNamespaces = []string{tagNamespace, storageNamespace, lockNamespace}
+
+// hashTag wraps the entity of a key in braces, so a cluster keeps the keys
+// of an entity in one slot and spreads the entities over the slots. The
+// entity is the name after the namespace for tags, locks and responses and
+// the first segment otherwise, e.g. tag:{users} and {users}:list. Keys with
+// a hash tag of their own are kept as they are.
+func hashTag(key string) string {
+	if open := strings.IndexByte(key, '{'); open >= 0 && strings.IndexByte(key[open:], '}') > 1 {
+		return key
+	}
+
+	for _, namespace := range hashedNamespaces {
+		if name, ok := strings.CutPrefix(key, namespace); ok {
+			return namespace + "{" + name + "}"
+		}
+	}
+
+	entity, rest, found := strings.Cut(key, ":")
+	if !found {
+		return "{" + key + "}"
+	}
+
+	return "{" + entity + "}:" + rest
+}
+
+// hashPattern is hashTag for a glob-style pattern of keys
+func hashPattern(pattern string) string {
+	for _, namespace := range hashedNamespaces {
+		if name, ok := strings.CutPrefix(pattern, namespace); ok {
+			return namespace + hashPatternEntity(name)
+		}
+	}
+
+	entity, rest, found := strings.Cut(pattern, ":")
+	if !found {
+		return hashPatternEntity(pattern)
+	}
+
+	return "{" + entity + "}:" + rest
+}
+
+// hashPatternEntity leaves the closing brace to a trailing star, so users*
+// also matches the keys below {users}
+func hashPatternEntity(entity string) string {
+	if strings.HasSuffix(entity, "*") {
+		return "{" + entity
+	}
+
+	return "{" + entity + "}"
+}
+
+// keyPattern is key for a glob-style pattern of keys
+func (c *Cache) keyPattern(pattern string) string {
+	if c.colocate {
+		pattern = hashPattern(pattern)
+	}
+
+	return c.namespace(pattern)
+}
+
+// namespace prefixes a key that is already hashed
+func (c *Cache) namespace(key string) string {
+	if len(c.prefix) > 0 {
+		return c.prefix + ":" + key
+	}
+
+	return key
+}
+
+// Tag sets live in their own namespace so they can be listed
+func (c *Cache) tagKey(tag string) string {
+	return c.key(tagNamespace + tag)
+}
+
+func (c *Cache) Get(ctx context.Context, key string, val interface{}) error {
+	ctx, span := tracer.Start(ctx, "CacheGet", trace.WithAttributes(attribute.String("key", key)))
+	defer span.End()
+
+	raw, err := c.read(ctx, c.key(key))
+	if err != nil {
+		if err == ErrMiss {
+			return nil
+		}
+		return err
+	}
+
+	err = c.decode(raw, val)
+	if err != nil {
+		fmt.Println("c.decode err:", err)
+		return err
+	}
+
+	return nil
+}
+
+// read returns the encoded value from the local tier or the backend, ErrMiss on a miss
+func (c *Cache) read(ctx context.Context, key string) ([]byte, error) {
+	if c.local != nil {
+		if raw, ok := c.local.get(key); ok {
+			c.metrics.hit(ctx, "local")
+			return raw, nil
+		}
+	}
+
+	var epoch uint64
+	if c.local != nil {
+		epoch = c.local.current()
+	}
+
+	start := time.Now()
+	raw, err := c.backend.Get(ctx, key)
+	c.metrics.observe(ctx, "get", start, err)
+	if err != nil {
+		if err == ErrMiss {
+			c.metrics.miss(ctx)
+		} else {
+			fmt.Println("c.backend.Get err:", err)
+		}
+		return nil, err
+	}
+	c.metrics.hit(ctx, "backend")
+
+	if c.local != nil {
+		// Keep the local copy no longer than the backend keeps the key,
+		// set caps it at the local TTL and -1 (no expiry) means the local TTL
+		if ttl, err := c.backend.TTL(ctx, key); err == nil {
+			c.local.setIf(epoch, key, raw, ttl)
+		}
+	}
+
+	return raw, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, val interface{}) error {
+	return c.SetWithTTL(ctx, key, val, c.expired)
+}
+
+// SetWithTTL is Set with a TTL other than the cache default
+func (c *Cache) SetWithTTL(ctx context.Context, key string, val interface{}, ttl time.Duration) error {
+	ctx, span := tracer.Start(ctx, "CacheSet", trace.WithAttributes(attribute.String("key", key), attribute.StringSlice("tags", c.tags)))
+	defer span.End()
+
+	value, err := c.encode(val)
+	if err != nil {
+		fmt.Println("c.encode err:", err)
+		return err
+	}
+
+	return c.store(ctx, c.key(key), value, ttl)
+}
+
+// store writes an encoded value and adds the key to every tag of the view
+func (c *Cache) store(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	start := time.Now()
+	err := c.backend.Set(ctx, key, value, ttl, c.tagKeys())
+	c.metrics.observe(ctx, "set", start, err)
+	if err != nil {
+		fmt.Println("c.backend.Set err:", err)
+		return err
+	}
+
+	if c.local != nil {
+		// Drop stale copies elsewhere before keeping the new value here
+		c.publish(ctx, key)
+		c.local.put(key, value, ttl)
+	}
+
+	return nil
+}
+
+func (c *Cache) Flush(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "CacheFlush", trace.WithAttributes(attribute.StringSlice("tags", c.tags)))
+	defer span.End()
+
+	start := time.Now()
+	err := c.backend.Flush(ctx, c.tagKeys(), func(keys []string) {
+		c.publish(ctx, keys...)
+	})
+	c.metrics.observe(ctx, "flush", start, err)
+	if err != nil {
+		fmt.Println("c.backend.Flush err:", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Cache) tagKeys() []string {
+	keys := make([]string, len(c.tags))
+	for i, tag := range c.tags {
+		keys[i] = c.tagKey(tag)
+	}
+
+	return keys
+}
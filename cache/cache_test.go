@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestCache(t *testing.T) (*Cache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewCache(NewRedisBackend(client), WithPrefix("test"), WithExpired(time.Minute)), server
+}
+
+func TestTagReturnsNewView(t *testing.T) {
+	cacher, _ := newTestCache(t)
+
+	users := cacher.Tag("users")
+	members := users.Tag("members")
+
+	if users == cacher || members == users {
+		t.Fatal("Tag must return a new *Cache")
+	}
+	if len(cacher.tags) != 0 {
+		t.Fatalf("base cache tags = %v, want none", cacher.tags)
+	}
+	if len(users.tags) != 1 || users.tags[0] != "users" {
+		t.Fatalf("users view tags = %v, want [users]", users.tags)
+	}
+	if len(members.tags) != 1 || members.tags[0] != "members" {
+		t.Fatalf("members view tags = %v, want [members]", members.tags)
+	}
+}
+
+func TestTagCopiesArguments(t *testing.T) {
+	cacher, _ := newTestCache(t)
+
+	tags := []string{"users"}
+	tagged := cacher.Tag(tags...)
+	tags[0] = "changed"
+
+	if tagged.tags[0] != "users" {
+		t.Fatalf("tagged view changed with the caller slice: %v", tagged.tags)
+	}
+}
+
+func TestConcurrentSetIsolatedByTag(t *testing.T) {
+	const (
+		tagCount    = 8
+		keysPerTags = 25
+	)
+	cacher, server := newTestCache(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < tagCount; i++ {
+		for j := 0; j < keysPerTags; j++ {
+			wg.Add(1)
+			go func(i, j int) {
+				defer wg.Done()
+				key := fmt.Sprintf("key_%d_%d", i, j)
+				if err := cacher.Tag(fmt.Sprintf("tag_%d", i)).Set(ctx, key, j); err != nil {
+					t.Error(err)
+				}
+			}(i, j)
+		}
+	}
+	wg.Wait()
+
+	for i := 0; i < tagCount; i++ {
+		members, err := server.Members(fmt.Sprintf("test:tag:tag_%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := make([]string, 0, keysPerTags)
+		for j := 0; j < keysPerTags; j++ {
+			want = append(want, fmt.Sprintf("test:key_%d_%d", i, j))
+		}
+		sort.Strings(want)
+		sort.Strings(members)
+
+		if fmt.Sprint(members) != fmt.Sprint(want) {
+			t.Errorf("tag_%d members = %v, want %v", i, members, want)
+		}
+	}
+}
+
+func TestConcurrentFlushIsolatedByTag(t *testing.T) {
+	const keyCount = 50
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+
+	for i := 0; i < keyCount; i++ {
+		if err := cacher.Tag("flushed").Set(ctx, fmt.Sprintf("flushed_%d", i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Flush one tag while another tag is written through the same cache
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < keyCount; i++ {
+			if err := cacher.Tag("kept").Set(ctx, fmt.Sprintf("kept_%d", i), i); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			if err := cacher.Tag("flushed").Flush(ctx); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	for i := 0; i < keyCount; i++ {
+		var flushed *int
+		if err := cacher.Get(ctx, fmt.Sprintf("flushed_%d", i), &flushed); err != nil {
+			t.Fatal(err)
+		}
+		if flushed != nil {
+			t.Errorf("flushed_%d is still cached", i)
+		}
+
+		var kept *int
+		if err := cacher.Get(ctx, fmt.Sprintf("kept_%d", i), &kept); err != nil {
+			t.Fatal(err)
+		}
+		if kept == nil || *kept != i {
+			t.Errorf("kept_%d = %v, want %d", i, kept, i)
+		}
+	}
+}
+
+func TestFlushLargeTagInBatches(t *testing.T) {
+	const keyCount = scanCount*2 + 50
+	cacher, server := newTestCache(t)
+	ctx := context.Background()
+
+	for i := 0; i < keyCount; i++ {
+		if err := cacher.Tag("users", fmt.Sprintf("user:%d", i)).Set(ctx, fmt.Sprintf("GetUser_%d", i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An entity tag only evicts its own entry
+	if err := cacher.Tag("user:7").Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("test:GetUser_7") || !server.Exists("test:GetUser_8") {
+		t.Fatal("entity flush evicted the wrong keys")
+	}
+
+	if err := cacher.Tag("users").Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range server.Keys() {
+		if !strings.HasPrefix(key, "test:tag:user:") {
+			t.Fatalf("%s left after flush", key)
+		}
+	}
+}
+
+func TestHashTagSpreadsEntities(t *testing.T) {
+	for key, want := range map[string]string{
+		"users:1":               "{users}:1",
+		"GetUser_7":             "{GetUser_7}",
+		"tag:user:7":            "tag:{user:7}",
+		"lock:GetUser_7":        "lock:{GetUser_7}",
+		"response:GET:/users?:": "response:{GET:/users?:}",
+		"idempotency:{abc}":     "idempotency:{abc}",
+	} {
+		if got := hashTag(key); got != want {
+			t.Fatalf("hashTag(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	for pattern, want := range map[string]string{
+		"*":        "{*",
+		"users:*":  "{users}:*",
+		"GetUser*": "{GetUser*",
+		"tag:*":    "tag:{*",
+	} {
+		if got := hashPattern(pattern); got != want {
+			t.Fatalf("hashPattern(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes cached values, use one of JSON, GoJSON or MsgPack
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	// format is written in the header byte so an entry is always read with
+	// the wire format it was written in
+	format() byte
+}
+
+var (
+	// JSON uses encoding/json
+	JSON Codec = jsonCodec{}
+	// GoJSON uses goccy/go-json, the same wire format as JSON
+	GoJSON Codec = goJSONCodec{}
+	// MsgPack is smaller and faster than JSON, struct fields follow their json tags
+	MsgPack Codec = msgPackCodec{}
+)
+
+// ParseCodec returns the codec for a CACHE_CODEC name
+func ParseCodec(name string) (Codec, error) {
+	switch name {
+	case "json":
+		return JSON, nil
+	case "gojson", "":
+		return GoJSON, nil
+	case "msgpack":
+		return MsgPack, nil
+	}
+
+	return nil, fmt.Errorf("cache: unknown codec %q", name)
+}
+
+// ErrUnknownFormat is returned for entries written by a newer, unknown codec
+var ErrUnknownFormat = errors.New("cache: unknown value format")
+
+// Encoded values are either plain JSON, as written before codecs existed, or
+// a header byte followed by the payload. JSON never starts with a byte of
+// 0x80 or above, so both kinds coexist under the same keys.
+//
+//	header = headerFlag | format<<4 | compression
+const headerFlag byte = 0x80
+
+const (
+	formatJSON    byte = 0
+	formatMsgPack byte = 1
+)
+
+const (
+	compressionNone byte = 0
+	compressionZstd byte = 1
+)
+
+// Decompressed values above this size are rejected
+const maxDecodedSize = 64 << 20
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) format() byte                               { return formatJSON }
+
+type goJSONCodec struct{}
+
+func (goJSONCodec) Marshal(v interface{}) ([]byte, error)      { return gojson.Marshal(v) }
+func (goJSONCodec) Unmarshal(data []byte, v interface{}) error { return gojson.Unmarshal(data, v) }
+func (goJSONCodec) format() byte                               { return formatJSON }
+
+type msgPackCodec struct{}
+
+func (msgPackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (msgPackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+
+	return dec.Decode(v)
+}
+
+func (msgPackCodec) format() byte { return formatMsgPack }
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+// zstdCoders returns the shared encoder and decoder, both are safe for
+// concurrent EncodeAll and DecodeAll calls
+func zstdCoders() (*zstd.Encoder, *zstd.Decoder) {
+	zstdOnce.Do(func() {
+		zstdEncoder, _ = zstd.NewWriter(nil)
+		zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecodedSize))
+	})
+
+	return zstdEncoder, zstdDecoder
+}
+
+// encode marshals the value with the cache codec and compresses it when it
+// is above the threshold. Uncompressed JSON is written without a header so
+// instances that predate codecs can still read it.
+func (c *Cache) encode(v interface{}) ([]byte, error) {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	compression := compressionNone
+	if c.compressAbove > 0 && len(data) >= c.compressAbove {
+		compression = compressionZstd
+	}
+	if c.codec.format() == formatJSON && compression == compressionNone {
+		return data, nil
+	}
+
+	encoded := make([]byte, 1, len(data)/2+1)
+	encoded[0] = headerFlag | c.codec.format()<<4 | compression
+	if compression == compressionZstd {
+		encoder, _ := zstdCoders()
+		return encoder.EncodeAll(data, encoded), nil
+	}
+
+	return append(encoded, data...), nil
+}
+
+// decode reads a value written by encode with any codec or compression
+func (c *Cache) decode(raw []byte, v interface{}) error {
+	codec, payload, err := c.unwrap(raw)
+	if err != nil {
+		return err
+	}
+
+	return codec.Unmarshal(payload, v)
+}
+
+// unwrap strips the header and decompresses, returning the codec to use
+func (c *Cache) unwrap(raw []byte) (Codec, []byte, error) {
+	jsonReader := c.codec
+	if jsonReader.format() != formatJSON {
+		jsonReader = GoJSON
+	}
+
+	if len(raw) == 0 || raw[0]&headerFlag == 0 {
+		return jsonReader, raw, nil
+	}
+
+	var codec Codec
+	switch (raw[0] &^ headerFlag) >> 4 {
+	case formatJSON:
+		codec = jsonReader
+	case formatMsgPack:
+		codec = MsgPack
+	default:
+		return nil, nil, ErrUnknownFormat
+	}
+
+	payload := raw[1:]
+	switch raw[0] & 0x0f {
+	case compressionNone:
+	case compressionZstd:
+		_, decoder := zstdCoders()
+		decompressed, err := decoder.DecodeAll(payload, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		payload = decompressed
+	default:
+		return nil, nil, ErrUnknownFormat
+	}
+
+	return codec, payload, nil
+}
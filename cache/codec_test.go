@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type codecSample struct {
+	ID     int       `json:"id"`
+	Name   string    `json:"name"`
+	Secret string    `json:"-"`
+	Tags   []string  `json:"tags"`
+	At     time.Time `json:"at"`
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	sample := codecSample{ID: 1, Name: strings.Repeat("member ", 100), Secret: "hidden", Tags: []string{"gold"}, At: time.Unix(1700000000, 0).UTC()}
+
+	for name, codec := range map[string]Codec{"json": JSON, "gojson": GoJSON, "msgpack": MsgPack} {
+		for _, threshold := range []int{0, 64} {
+			c := &Cache{codec: codec, compressAbove: threshold}
+
+			raw, err := c.encode(sample)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			var got codecSample
+			if err := c.decode(raw, &got); err != nil {
+				t.Fatalf("%s/%d: %v", name, threshold, err)
+			}
+			if got.ID != sample.ID || got.Name != sample.Name || got.Secret != "" || !got.At.Equal(sample.At) {
+				t.Fatalf("%s/%d: got %+v", name, threshold, got)
+			}
+		}
+	}
+}
+
+func TestPlainJSONHasNoHeader(t *testing.T) {
+	c := &Cache{codec: GoJSON}
+
+	raw, err := c.encode(map[string]int{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `{"id":1}` {
+		t.Fatalf("raw = %q, want plain JSON readable by older instances", raw)
+	}
+}
+
+func TestCompressionShrinksLargeValues(t *testing.T) {
+	value := strings.Repeat("paginated list ", 1000)
+	plain := &Cache{codec: GoJSON}
+	compressed := &Cache{codec: GoJSON, compressAbove: 1024}
+
+	plainRaw, _ := plain.encode(value)
+	compressedRaw, _ := compressed.encode(value)
+
+	if compressedRaw[0] != headerFlag|formatJSON<<4|compressionZstd {
+		t.Fatalf("header = %#x", compressedRaw[0])
+	}
+	if len(compressedRaw) >= len(plainRaw)/4 {
+		t.Fatalf("compressed %d bytes, plain %d bytes", len(compressedRaw), len(plainRaw))
+	}
+}
+
+func TestEntriesOfOtherCodecsStayReadable(t *testing.T) {
+	backend := NewMemoryBackend()
+	defer backend.Close()
+	ctx := context.Background()
+
+	old := NewCache(backend, WithPrefix("test"), WithCodec(JSON))
+	current := NewCache(backend, WithPrefix("test"), WithCodec(MsgPack), WithCompression(16))
+
+	if err := old.Set(ctx, "legacy", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := current.Set(ctx, "fresh", []string{"c", "d", "e", "f", "g"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var legacy, fresh []string
+	if err := current.Get(ctx, "legacy", &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.Get(ctx, "fresh", &fresh); err != nil {
+		t.Fatal(err)
+	}
+	if len(legacy) != 2 || len(fresh) != 5 {
+		t.Fatalf("legacy = %v, fresh = %v", legacy, fresh)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	c := &Cache{codec: GoJSON}
+
+	var val interface{}
+	if err := c.decode([]byte{headerFlag | 7<<4, 1}, &val); err != ErrUnknownFormat {
+		t.Fatalf("err = %v, want ErrUnknownFormat", err)
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// invalidation is published whenever keys are overwritten or flushed so
+// that every replica and prefork child drops its local copies
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+func (c *Cache) invalidationChannel() string {
+	return c.key("cache:invalidate")
+}
+
+// publish evicts the keys locally and tells the other instances to do the same
+func (c *Cache) publish(ctx context.Context, keys ...string) {
+	if c.local == nil || len(keys) == 0 {
+		return
+	}
+
+	c.local.delete(keys...)
+
+	payload, err := json.Marshal(invalidation{Origin: c.instanceID, Keys: keys})
+	if err != nil {
+		fmt.Println("json.Marshal err:", err)
+		return
+	}
+
+	if err := c.backend.Publish(ctx, c.invalidationChannel(), payload); err != nil {
+		// Local copies elsewhere expire with the local TTL
+		fmt.Println("c.backend.Publish err:", err)
+	}
+}
+
+// invalidate applies an invalidation published by another instance
+func (c *Cache) invalidate(payload []byte) {
+	var inv invalidation
+	if err := json.Unmarshal(payload, &inv); err != nil {
+		fmt.Println("json.Unmarshal err:", err)
+		return
+	}
+
+	if inv.Origin != c.instanceID {
+		c.local.delete(inv.Keys...)
+	}
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// local is an in-process LRU with a byte budget and a per-entry TTL. It holds
+// the encoded values exactly as they are stored in Redis.
+type local struct {
+	mu       sync.Mutex
+	maxBytes int64
+	ttl      time.Duration
+	size     int64
+	ll       *list.List
+	items    map[string]*list.Element
+	// Bumped on every invalidation so a Redis read that raced a Flush is not
+	// written back into the local tier
+	epoch uint64
+}
+
+type localItem struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func newLocal(maxBytes int64, ttl time.Duration) *local {
+	return &local{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (l *local) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := el.Value.(*localItem)
+	if time.Now().After(item.expiresAt) {
+		l.remove(el)
+		return nil, false
+	}
+
+	l.ll.MoveToFront(el)
+	return item.value, true
+}
+
+// current returns the epoch to pass to setIf
+func (l *local) current() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.epoch
+}
+
+// setIf stores the value unless an invalidation happened since epoch
+func (l *local) setIf(epoch uint64, key string, value []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.epoch != epoch {
+		return
+	}
+	l.set(key, value, ttl)
+}
+
+func (l *local) put(key string, value []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.set(key, value, ttl)
+}
+
+// set stores the value for the shorter of ttl and the local TTL, values
+// larger than the whole budget are not kept
+func (l *local) set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 || ttl > l.ttl {
+		ttl = l.ttl
+	}
+
+	if el, ok := l.items[key]; ok {
+		l.remove(el)
+	}
+
+	size := int64(len(key) + len(value))
+	if size > l.maxBytes {
+		return
+	}
+
+	item := &localItem{key: key, value: value, expiresAt: time.Now().Add(ttl)}
+	l.items[key] = l.ll.PushFront(item)
+	l.size += size
+
+	for l.size > l.maxBytes {
+		l.remove(l.ll.Back())
+	}
+}
+
+func (l *local) delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.epoch++
+	for _, key := range keys {
+		if el, ok := l.items[key]; ok {
+			l.remove(el)
+		}
+	}
+}
+
+// purge drops every entry, used when invalidation messages may have been lost
+func (l *local) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.epoch++
+	l.ll.Init()
+	l.items = make(map[string]*list.Element)
+	l.size = 0
+}
+
+// usage returns the number of entries and their size in bytes
+func (l *local) usage() (int, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.ll.Len(), l.size
+}
+
+func (l *local) remove(el *list.Element) {
+	item := el.Value.(*localItem)
+	l.ll.Remove(el)
+	delete(l.items, item.key)
+	l.size -= int64(len(item.key) + len(item.value))
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestLocalEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLocal(30, time.Minute)
+
+	l.put("a", []byte("0123456789"), 0) // 11 bytes
+	l.put("b", []byte("0123456789"), 0) // 22 bytes
+	if _, ok := l.get("a"); !ok {
+		t.Fatal("a should be cached")
+	}
+	l.put("c", []byte("0123456789"), 0) // 33 bytes, evicts b
+
+	if _, ok := l.get("b"); ok {
+		t.Fatal("b should have been evicted")
+	}
+	if _, ok := l.get("a"); !ok {
+		t.Fatal("a should still be cached")
+	}
+	if l.size > l.maxBytes {
+		t.Fatalf("size = %d, over budget %d", l.size, l.maxBytes)
+	}
+}
+
+func TestLocalSkipsOversizedValues(t *testing.T) {
+	l := newLocal(8, time.Minute)
+
+	l.put("big", []byte("0123456789"), 0)
+
+	if _, ok := l.get("big"); ok {
+		t.Fatal("value larger than the budget must not be kept")
+	}
+}
+
+func TestLocalExpires(t *testing.T) {
+	l := newLocal(100, time.Minute)
+
+	l.put("short", []byte("x"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := l.get("short"); ok {
+		t.Fatal("expired value must not be served")
+	}
+}
+
+func TestLocalSetIfSkipsAfterInvalidation(t *testing.T) {
+	l := newLocal(100, time.Minute)
+
+	epoch := l.current()
+	l.delete("key")
+	l.setIf(epoch, "key", []byte("stale"), 0)
+
+	if _, ok := l.get("key"); ok {
+		t.Fatal("value read before an invalidation must not be cached")
+	}
+}
+
+func newLocalTestCaches(t *testing.T) (*Cache, *Cache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	open := func() *Cache {
+		c := NewCache(NewRedisBackend(redis.NewClient(&redis.Options{Addr: server.Addr()})), WithPrefix("test"), WithExpired(time.Minute), WithLocal(1<<20, time.Minute))
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	return open(), open(), server
+}
+
+func TestLocalServesWithoutRedis(t *testing.T) {
+	cacher, _, server := newLocalTestCaches(t)
+	ctx := context.Background()
+
+	if err := cacher.Set(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove the key behind the cache's back, the local copy still answers
+	server.Del("test:key")
+
+	var val string
+	if err := cacher.Get(ctx, "key", &val); err != nil {
+		t.Fatal(err)
+	}
+	if val != "value" {
+		t.Fatalf("val = %q, want local copy", val)
+	}
+}
+
+func TestLocalKeepsRemainingBackendTTL(t *testing.T) {
+	reader, writer, server := newLocalTestCaches(t)
+	ctx := context.Background()
+
+	if err := writer.SetWithTTL(ctx, "key", "value", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	var val string
+	if err := reader.Get(ctx, "key", &val); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	server.FastForward(100 * time.Millisecond)
+
+	if _, ok := reader.local.get("test:key"); ok {
+		t.Fatal("local copy outlived the backend TTL")
+	}
+	val = ""
+	if err := reader.Get(ctx, "key", &val); err != nil {
+		t.Fatal(err)
+	}
+	if val != "" {
+		t.Fatalf("val = %q, want a miss after the backend TTL", val)
+	}
+}
+
+func TestFlushInvalidatesOtherInstances(t *testing.T) {
+	reader, writer, _ := newLocalTestCaches(t)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if err := writer.Tag("users").Set(ctx, fmt.Sprintf("user_%d", i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Warm the reader's local tier
+	for i := 0; i < 10; i++ {
+		var val *int
+		if err := reader.Get(ctx, fmt.Sprintf("user_%d", i), &val); err != nil {
+			t.Fatal(err)
+		}
+		if val == nil {
+			t.Fatalf("user_%d missing", i)
+		}
+	}
+
+	if err := writer.Tag("users").Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("test:user_%d", i)
+		for {
+			if _, ok := reader.local.get(key); !ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s still cached locally after flush", key)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestSetInvalidatesOtherInstances(t *testing.T) {
+	reader, writer, _ := newLocalTestCaches(t)
+	ctx := context.Background()
+
+	if err := writer.Set(ctx, "key", "old"); err != nil {
+		t.Fatal(err)
+	}
+	var val string
+	if err := reader.Get(ctx, "key", &val); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Set(ctx, "key", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if err := reader.Get(ctx, "key", &val); err != nil {
+			t.Fatal(err)
+		}
+		if val == "new" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("val = %q, want new value after overwrite", val)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
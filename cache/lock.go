@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Namespace of locks below the cache prefix
+const lockNamespace = "lock:"
+
+var (
+	// ErrLockNotAcquired is returned by TryLock while another owner holds the lock
+	ErrLockNotAcquired = errors.New("cache: lock is held by another owner")
+	// ErrLockNotHeld is returned by Unlock when the lock expired or was taken over
+	ErrLockNotHeld = errors.New("cache: lock is no longer held")
+	// ErrInvalidLockTTL is returned by TryLock for a TTL too short to be extended
+	ErrInvalidLockTTL = errors.New("cache: lock ttl must be positive")
+)
+
+// Lock is a mutual exclusion lock shared by every instance using the same
+// backend. It is owned by a random token and extended in the background
+// until Unlock, so the TTL only bounds how long a crashed owner blocks others.
+type Lock struct {
+	cache *Cache
+	key   string
+	token []byte
+	ttl   time.Duration
+
+	stop     chan struct{}
+	done     chan struct{}
+	lost     chan struct{}
+	stopOnce sync.Once
+}
+
+// TryLock acquires the named lock once, ErrLockNotAcquired when it is held
+func (c *Cache) TryLock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	ctx, span := tracer.Start(ctx, "CacheTryLock")
+	defer span.End()
+
+	// The lock is extended every third of the TTL, which must not round to zero
+	if ttl/3 <= 0 {
+		return nil, ErrInvalidLockTTL
+	}
+
+	l := &Lock{
+		cache: c,
+		key:   c.key(lockNamespace + name),
+		token: []byte(newID()),
+		ttl:   ttl,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		lost:  make(chan struct{}),
+	}
+
+	acquired, err := c.backend.SetNX(ctx, l.key, l.token, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrLockNotAcquired
+	}
+
+	go l.extend()
+
+	return l, nil
+}
+
+// Lock waits for the named lock until it is acquired or ctx is done
+func (c *Cache) Lock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	wait := 10 * time.Millisecond
+	for {
+		l, err := c.TryLock(ctx, name, ttl)
+		if err != ErrLockNotAcquired {
+			return l, err
+		}
+
+		// Jittered exponential backoff so waiters do not retry in lockstep
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait/2 + time.Duration(rand.Int63n(int64(wait)))):
+		}
+		if wait < 500*time.Millisecond {
+			wait *= 2
+		}
+	}
+}
+
+// extend renews the TTL every third of it until Unlock or until the lock is lost
+func (l *Lock) extend() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			extended, err := l.cache.backend.CompareAndExpire(ctx, l.key, l.token, l.ttl)
+			cancel()
+			if err != nil {
+				// The lock still has time left, retry on the next tick
+				continue
+			}
+			if !extended {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+// Lost is closed when the lock expired or was taken over before Unlock,
+// work guarded by the lock should stop
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Unlock stops extending the lock and releases it, ErrLockNotHeld when it
+// was lost in the meantime
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+
+	released, err := l.cache.backend.CompareAndDelete(ctx, l.key, l.token)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
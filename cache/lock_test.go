@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTryLockIsExclusive(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+
+	lock, err := cacher.TryLock(ctx, "booking:42", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cacher.TryLock(ctx, "booking:42", time.Second); err != ErrLockNotAcquired {
+		t.Fatalf("err = %v, want ErrLockNotAcquired", err)
+	}
+
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	again, err := cacher.TryLock(ctx, "booking:42", time.Second)
+	if err != nil {
+		t.Fatalf("lock not released: %v", err)
+	}
+	again.Unlock(ctx)
+}
+
+func TestTryLockRejectsInvalidTTL(t *testing.T) {
+	cacher, server := newTestCache(t)
+	ctx := context.Background()
+
+	for _, ttl := range []time.Duration{0, -time.Second, 2 * time.Nanosecond} {
+		if _, err := cacher.TryLock(ctx, "job", ttl); err != ErrInvalidLockTTL {
+			t.Fatalf("ttl %v: err = %v, want ErrInvalidLockTTL", ttl, err)
+		}
+	}
+	if server.Exists("test:lock:job") {
+		t.Fatal("an invalid ttl reached the backend")
+	}
+}
+
+func TestUnlockDoesNotReleaseAnotherOwner(t *testing.T) {
+	cacher, server := newTestCache(t)
+	ctx := context.Background()
+
+	lock, err := cacher.TryLock(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate expiry and a new owner taking over
+	server.Set("test:lock:job", "someone-else")
+
+	if err := lock.Unlock(ctx); err != ErrLockNotHeld {
+		t.Fatalf("err = %v, want ErrLockNotHeld", err)
+	}
+	if value, _ := server.Get("test:lock:job"); value != "someone-else" {
+		t.Fatal("unlock released a lock it did not own")
+	}
+}
+
+func TestLockIsExtendedUntilUnlock(t *testing.T) {
+	backend := NewMemoryBackend()
+	defer backend.Close()
+	cacher := NewCache(backend, WithPrefix("test"))
+	ctx := context.Background()
+
+	lock, err := cacher.TryLock(ctx, "job", 60*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if _, err := cacher.TryLock(ctx, "job", time.Second); err != ErrLockNotAcquired {
+		t.Fatal("lock expired while its owner was alive")
+	}
+
+	select {
+	case <-lock.Lost():
+		t.Fatal("lock reported lost")
+	default:
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockReportsLoss(t *testing.T) {
+	backend := NewMemoryBackend()
+	defer backend.Close()
+	cacher := NewCache(backend, WithPrefix("test"))
+	ctx := context.Background()
+
+	lock, err := cacher.TryLock(ctx, "job", 60*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Delete(ctx, "test:lock:job")
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("loss was not reported")
+	}
+}
+
+func TestLockSerializesWaiters(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		inside  int32
+		overlap int32
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := cacher.Lock(ctx, "shared", time.Second)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if atomic.AddInt32(&inside, 1) > 1 {
+				atomic.StoreInt32(&overlap, 1)
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inside, -1)
+			lock.Unlock(ctx)
+		}()
+	}
+	wg.Wait()
+
+	if overlap != 0 {
+		t.Fatal("two owners held the lock at once")
+	}
+}
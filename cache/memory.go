@@ -0,0 +1,271 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"sync"
+	"time"
+)
+
+// memoryBackend keeps everything in process, for tests and local development.
+// Pub/sub only reaches caches sharing the same backend.
+type memoryBackend struct {
+	mu          sync.Mutex
+	items       map[string]memoryItem
+	tags        map[string]map[string]struct{}
+	subscribers map[string]map[*memorySubscription]struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && now.After(i.expiresAt)
+}
+
+func NewMemoryBackend() Backend {
+	b := &memoryBackend{
+		items:       make(map[string]memoryItem),
+		tags:        make(map[string]map[string]struct{}),
+		subscribers: make(map[string]map[*memorySubscription]struct{}),
+		done:        make(chan struct{}),
+	}
+	go b.sweep(time.Minute)
+
+	return b
+}
+
+// sweep drops expired values so memory does not grow with dead keys
+func (b *memoryBackend) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case now := <-ticker.C:
+			b.mu.Lock()
+			for key, item := range b.items {
+				if item.expired(now) {
+					delete(b.items, key)
+				}
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+func (b *memoryBackend) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (b *memoryBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	item, ok := b.items[key]
+	if !ok || item.expired(time.Now()) {
+		return nil, ErrMiss
+	}
+
+	return item.value, nil
+}
+
+func (b *memoryBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tagKeys []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, tagKey := range tagKeys {
+		if b.tags[tagKey] == nil {
+			b.tags[tagKey] = make(map[string]struct{})
+		}
+		b.tags[tagKey][key] = struct{}{}
+	}
+
+	item := memoryItem{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+	b.items[key] = item
+
+	return nil
+}
+
+func (b *memoryBackend) Flush(ctx context.Context, tagKeys []string, flushed func(keys []string)) error {
+	for _, tagKey := range tagKeys {
+		b.mu.Lock()
+		keys := make([]string, 0, len(b.tags[tagKey]))
+		for key := range b.tags[tagKey] {
+			keys = append(keys, key)
+			delete(b.items, key)
+		}
+		delete(b.tags, tagKey)
+		b.mu.Unlock()
+
+		if len(keys) > 0 {
+			flushed(keys)
+		}
+	}
+
+	return nil
+}
+
+func (b *memoryBackend) Scan(ctx context.Context, match string, fn func(keys []string) error) error {
+	b.mu.Lock()
+	now := time.Now()
+	var keys []string
+	for key, item := range b.items {
+		if ok, _ := path.Match(match, key); ok && !item.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	for tagKey := range b.tags {
+		if ok, _ := path.Match(match, tagKey); ok {
+			keys = append(keys, tagKey)
+		}
+	}
+	b.mu.Unlock()
+
+	if len(keys) == 0 {
+		return nil
+	}
+	return fn(keys)
+}
+
+func (b *memoryBackend) Count(ctx context.Context, tagKey string) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return int64(len(b.tags[tagKey])), nil
+}
+
+func (b *memoryBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.tags[key]; ok {
+		return -1, nil
+	}
+
+	item, ok := b.items[key]
+	if !ok || item.expired(time.Now()) {
+		return 0, ErrMiss
+	}
+	if item.expiresAt.IsZero() {
+		return -1, nil
+	}
+
+	return time.Until(item.expiresAt), nil
+}
+
+func (b *memoryBackend) Delete(ctx context.Context, keys ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		delete(b.items, key)
+		delete(b.tags, key)
+	}
+
+	return nil
+}
+
+func (b *memoryBackend) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if item, ok := b.items[key]; ok && !item.expired(time.Now()) {
+		return false, nil
+	}
+
+	item := memoryItem{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+	b.items[key] = item
+
+	return true, nil
+}
+
+func (b *memoryBackend) CompareAndDelete(ctx context.Context, key string, value []byte) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	item, ok := b.items[key]
+	if !ok || item.expired(time.Now()) || !bytes.Equal(item.value, value) {
+		return false, nil
+	}
+	delete(b.items, key)
+
+	return true, nil
+}
+
+func (b *memoryBackend) CompareAndExpire(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	item, ok := b.items[key]
+	if !ok || item.expired(time.Now()) || !bytes.Equal(item.value, value) {
+		return false, nil
+	}
+	item.expiresAt = time.Now().Add(ttl)
+	b.items[key] = item
+
+	return true, nil
+}
+
+func (b *memoryBackend) Publish(ctx context.Context, channel string, payload []byte) error {
+	b.mu.Lock()
+	subscribers := make([]*memorySubscription, 0, len(b.subscribers[channel]))
+	for s := range b.subscribers[channel] {
+		subscribers = append(subscribers, s)
+	}
+	b.mu.Unlock()
+
+	for _, s := range subscribers {
+		s.handle(payload)
+	}
+
+	return nil
+}
+
+func (b *memoryBackend) Subscribe(channel string, handle func(payload []byte), reset func()) io.Closer {
+	s := &memorySubscription{backend: b, channel: channel, handle: handle}
+
+	b.mu.Lock()
+	if b.subscribers[channel] == nil {
+		b.subscribers[channel] = make(map[*memorySubscription]struct{})
+	}
+	b.subscribers[channel][s] = struct{}{}
+	b.mu.Unlock()
+
+	return s
+}
+
+func (b *memoryBackend) Close() error {
+	b.closeOnce.Do(func() { close(b.done) })
+
+	return nil
+}
+
+type memorySubscription struct {
+	backend *memoryBackend
+	channel string
+	handle  func(payload []byte)
+}
+
+func (s *memorySubscription) Close() error {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	delete(s.backend.subscribers[s.channel], s)
+
+	return nil
+}
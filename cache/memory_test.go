@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newMemoryTestCache(t *testing.T, opts ...Option) *Cache {
+	t.Helper()
+
+	c := NewCache(NewMemoryBackend(), append([]Option{WithPrefix("test"), WithExpired(time.Minute)}, opts...)...)
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+func TestMemoryBackendSetGetFlush(t *testing.T) {
+	cacher := newMemoryTestCache(t)
+	ctx := context.Background()
+
+	if err := cacher.Tag("users").Set(ctx, "user_1", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacher.Tag("plans").Set(ctx, "plan_1", "gold"); err != nil {
+		t.Fatal(err)
+	}
+
+	var val *string
+	if err := cacher.Get(ctx, "user_1", &val); err != nil {
+		t.Fatal(err)
+	}
+	if val == nil || *val != "alice" {
+		t.Fatalf("val = %v, want alice", val)
+	}
+
+	if err := cacher.Tag("users").Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	val = nil
+	if err := cacher.Get(ctx, "user_1", &val); err != nil {
+		t.Fatal(err)
+	}
+	if val != nil {
+		t.Fatal("user_1 should have been flushed")
+	}
+	if err := cacher.Get(ctx, "plan_1", &val); err != nil {
+		t.Fatal(err)
+	}
+	if val == nil || *val != "gold" {
+		t.Fatal("plan_1 should not be flushed with another tag")
+	}
+}
+
+func TestMemoryBackendExpires(t *testing.T) {
+	backend := NewMemoryBackend()
+	defer backend.Close()
+	ctx := context.Background()
+
+	if err := backend.Set(ctx, "key", []byte("1"), time.Millisecond, nil); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := backend.Get(ctx, "key"); err != ErrMiss {
+		t.Fatalf("err = %v, want ErrMiss", err)
+	}
+}
+
+func TestMemoryBackendRemember(t *testing.T) {
+	cacher := newMemoryTestCache(t)
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(ctx context.Context) (map[string]interface{}, error) {
+		calls++
+		return map[string]interface{}{}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := Remember(ctx, cacher, "empty_map", 0, []string{"maps"}, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestMemoryBackendInvalidatesSharedCaches(t *testing.T) {
+	backend := NewMemoryBackend()
+	open := func() *Cache {
+		c := NewCache(backend, WithPrefix("test"), WithExpired(time.Minute), WithLocal(1<<20, time.Minute))
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+	reader, writer := open(), open()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := writer.Tag("users").Set(ctx, fmt.Sprintf("user_%d", i), i); err != nil {
+			t.Fatal(err)
+		}
+		var val *int
+		if err := reader.Get(ctx, fmt.Sprintf("user_%d", i), &val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Tag("users").Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, ok := reader.local.get(fmt.Sprintf("test:user_%d", i)); ok {
+			t.Fatalf("user_%d still cached locally after flush", i)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
+
+var tracer = otel.Tracer(instrumentationName)
+
+// Stats are the totals of this process since start
+type Stats struct {
+	Hits       int64   `json:"hits"`
+	LocalHits  int64   `json:"local_hits"`
+	Misses     int64   `json:"misses"`
+	Errors     int64   `json:"errors"`
+	Sets       int64   `json:"sets"`
+	Flushes    int64   `json:"flushes"`
+	HitRatio   float64 `json:"hit_ratio"`
+	LocalKeys  int     `json:"local_keys"`
+	LocalBytes int64   `json:"local_bytes"`
+}
+
+// metrics is shared by every tagged view of a Cache
+type metrics struct {
+	hits    metric.Int64Counter
+	misses  metric.Int64Counter
+	errors  metric.Int64Counter
+	latency metric.Float64Histogram
+
+	totalHits      atomic.Int64
+	totalLocalHits atomic.Int64
+	totalMisses    atomic.Int64
+	totalErrors    atomic.Int64
+	totalSets      atomic.Int64
+	totalFlushes   atomic.Int64
+}
+
+func newMetrics() *metrics {
+	meter := otel.Meter(instrumentationName)
+	m := &metrics{}
+
+	// Instruments of the global meter never fail to be created, errors
+	// only come from invalid names
+	m.hits, _ = meter.Int64Counter("cache.hits", metric.WithDescription("Cache reads served from the cache"))
+	m.misses, _ = meter.Int64Counter("cache.misses", metric.WithDescription("Cache reads that found nothing"))
+	m.errors, _ = meter.Int64Counter("cache.errors", metric.WithDescription("Failed cache operations"))
+	m.latency, _ = meter.Float64Histogram("cache.duration", metric.WithDescription("Cache operation latency"), metric.WithUnit("ms"))
+
+	return m
+}
+
+// observe records the latency and outcome of an operation, tier is the
+// layer that answered a read
+func (m *metrics) observe(ctx context.Context, op string, start time.Time, err error) {
+	attrs := metric.WithAttributes(attribute.String("op", op))
+
+	m.latency.Record(ctx, float64(time.Since(start).Microseconds())/1000, attrs)
+	if err != nil && err != ErrMiss {
+		m.errors.Add(ctx, 1, attrs)
+		m.totalErrors.Add(1)
+		return
+	}
+
+	switch op {
+	case "set":
+		m.totalSets.Add(1)
+	case "flush":
+		m.totalFlushes.Add(1)
+	}
+}
+
+func (m *metrics) hit(ctx context.Context, tier string) {
+	m.hits.Add(ctx, 1, metric.WithAttributes(attribute.String("tier", tier)))
+	m.totalHits.Add(1)
+	if tier == "local" {
+		m.totalLocalHits.Add(1)
+	}
+}
+
+func (m *metrics) miss(ctx context.Context) {
+	m.misses.Add(ctx, 1)
+	m.totalMisses.Add(1)
+}
+
+// Stats returns the hit, miss and error totals of this process
+func (c *Cache) Stats() Stats {
+	stats := Stats{
+		Hits:      c.metrics.totalHits.Load(),
+		LocalHits: c.metrics.totalLocalHits.Load(),
+		Misses:    c.metrics.totalMisses.Load(),
+		Errors:    c.metrics.totalErrors.Load(),
+		Sets:      c.metrics.totalSets.Load(),
+		Flushes:   c.metrics.totalFlushes.Load(),
+	}
+	if reads := stats.Hits + stats.Misses; reads > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(reads)
+	}
+	if c.local != nil {
+		stats.LocalKeys, stats.LocalBytes = c.local.usage()
+	}
+
+	return stats
+}
@@ -2,169 +2,275 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
+	"io"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
-	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils/color"
 	"github.com/go-redis/redis/v8"
-	"github.com/gofiber/fiber/v2"
 )
 
-type Cache struct {
-	redis *redis.Client
-	// redisCluster *redis.ClusterClient
-	tags []string
+// Keys fetched per SCAN call and passed per batch to Scan and Flush callbacks
+const scanCount = 100
 
-	prefix  string
-	expired time.Duration
+// Lifetime of a renamed tag set left behind by an interrupted Flush
+const drainingTTL = time.Hour
+
+// redisBackend works with a single node, Sentinel failover or Cluster client
+type redisBackend struct {
+	client  redis.UniversalClient
+	cluster bool
 }
 
-var (
-	Client *redis.Client
-	Cacher *Cache
-)
+func NewRedisBackend(client redis.UniversalClient) Backend {
+	_, cluster := client.(*redis.ClusterClient)
 
-func Initialize() *redis.Client {
-	return redis.NewClient(&redis.Options{
-		Addr:     config.AppConfig.RedisAddr,
-		Username: config.AppConfig.RedisUsername,
-		Password: config.AppConfig.RedisPassword,
-	})
+	return &redisBackend{client: client, cluster: cluster}
 }
 
-func NewCache(opts ...Option) *Cache {
-	_, err := Client.Ping(context.Background()).Result()
-	if err != nil {
-		panic(err)
-	}
+func (b *redisBackend) colocate() bool {
+	return b.cluster
+}
 
-	if !fiber.IsChild() {
-		log.Println("Cache client connected", color.Format(color.GREEN, "successfully!"))
-	}
+func (b *redisBackend) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
+}
 
-	o := &Options{}
-	for _, opt := range opts {
-		opt(o)
+func (b *redisBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	raw, err := b.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
 	}
 
-	return &Cache{
-		redis:   Client,
-		prefix:  o.prefix,
-		expired: o.expired,
-	}
+	return raw, err
 }
 
-type Options struct {
-	prefix  string
-	expired time.Duration
+func (b *redisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tagKeys []string) error {
+	// A cluster runs a transaction per hash slot, the tag sets are
+	// written before the value either way
+	_, err := b.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, tagKey := range tagKeys {
+			p.SAdd(ctx, tagKey, key)
+		}
+		p.Set(ctx, key, value, ttl)
+		return nil
+	})
+
+	return err
 }
 
-type Option func(*Options)
+func (b *redisBackend) Flush(ctx context.Context, tagKeys []string, flushed func(keys []string)) error {
+	for _, tagKey := range tagKeys {
+		// Writes during the flush go to a fresh set instead of one that is
+		// about to be deleted, the draining set expires if the flush dies
+		draining := tagKey + drainingMarker + newID()
+		err := b.client.Rename(ctx, tagKey, draining).Err()
+		if err != nil {
+			if strings.Contains(err.Error(), "no such key") {
+				continue
+			}
+			return err
+		}
+		if err = b.client.Expire(ctx, draining, drainingTTL).Err(); err != nil {
+			return err
+		}
 
-func WithPrefix(prefix string) Option {
-	return func(o *Options) {
-		o.prefix = prefix
-	}
-}
+		var cursor uint64
+		for {
+			keys, next, err := b.client.SScan(ctx, draining, cursor, "", scanCount).Result()
+			if err != nil {
+				return err
+			}
+
+			if len(keys) > 0 {
+				if err = b.unlink(ctx, keys); err != nil {
+					return err
+				}
+				flushed(keys)
+			}
 
-func WithExpired(exp time.Duration) Option {
-	return func(o *Options) {
-		o.expired = exp
+			cursor = next
+			if cursor == 0 {
+				break
+			}
+		}
+
+		if err = b.client.Unlink(ctx, draining).Err(); err != nil {
+			return err
+		}
 	}
-}
 
-func (c *Cache) Tag(tag ...string) *Cache {
-	c.tags = tag
-	return c
+	return nil
 }
 
-func (c *Cache) Get(ctx context.Context, key string, val interface{}) error {
-	if len(c.prefix) > 0 {
-		key = c.prefix + ":" + key
+func (b *redisBackend) Scan(ctx context.Context, match string, fn func(keys []string) error) error {
+	// SCAN only walks the node it is sent to, masters are scanned in
+	// parallel so fn is serialized
+	if cluster, ok := b.client.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client, match, func(keys []string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return fn(keys)
+			})
+		})
 	}
 
-	jsonStr, err := c.redis.Get(ctx, key).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return nil
+	return scan(ctx, b.client, match, fn)
+}
+
+func scan(ctx context.Context, client redis.Cmdable, match string, fn func(keys []string) error) error {
+	iter := client.Scan(ctx, 0, match, scanCount).Iterator()
+	batch := make([]string, 0, scanCount)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == scanCount {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]string, 0, scanCount)
 		}
-		fmt.Println("c.redis.Get err:", err)
+	}
+	if err := iter.Err(); err != nil {
 		return err
 	}
-	err = json.Unmarshal([]byte(jsonStr), &val)
 
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+func (b *redisBackend) Count(ctx context.Context, tagKey string) (int64, error) {
+	return b.client.SCard(ctx, tagKey).Result()
+}
+
+func (b *redisBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := b.client.PTTL(ctx, key).Result()
 	if err != nil {
-		fmt.Println("json.Unmarshal err:", err)
-		return err
+		return 0, err
 	}
 
-	return nil
+	// go-redis passes the -2 (missing) and -1 (no expiry) replies through as is
+	switch ttl {
+	case -2:
+		return 0, ErrMiss
+	case -1:
+		return -1, nil
+	}
+
+	return ttl, nil
 }
 
-func (c *Cache) Set(ctx context.Context, key string, val interface{}) error {
-	if len(c.prefix) > 0 {
-		key = c.prefix + ":" + key
+func (b *redisBackend) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
 	}
 
-	_, err := c.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
-		for _, v := range c.tags {
-			err := p.SAdd(ctx, c.prefix+":"+v, key).Err()
-			if err != nil {
-				fmt.Println(fmt.Errorf("p.SAdd err %v", err))
-				return err
-			}
-		}
+	return b.unlink(ctx, keys)
+}
 
-		value, err := json.Marshal(val)
-		if err != nil {
-			fmt.Println("json.Marshal err:", err)
-			return err
-		}
+// unlink deletes the keys, one by one on a cluster where they may live in
+// different hash slots
+func (b *redisBackend) unlink(ctx context.Context, keys []string) error {
+	if !b.cluster {
+		return b.client.Unlink(ctx, keys...).Err()
+	}
 
-		err = p.Set(ctx, key, string(value), c.expired).Err()
-		if err != nil {
-			fmt.Println("p.Set err:", err)
-			return err
+	_, err := b.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, key := range keys {
+			p.Unlink(ctx, key)
 		}
 		return nil
 	})
-	if err != nil {
-		return err
+
+	return err
+}
+
+var compareAndDeleteScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+var compareAndExpireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+func (b *redisBackend) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return b.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (b *redisBackend) CompareAndDelete(ctx context.Context, key string, value []byte) (bool, error) {
+	deleted, err := compareAndDeleteScript.Run(ctx, b.client, []string{key}, value).Int()
+
+	return deleted == 1, err
+}
+
+func (b *redisBackend) CompareAndExpire(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	extended, err := compareAndExpireScript.Run(ctx, b.client, []string{key}, value, ttl.Milliseconds()).Int()
+
+	return extended == 1, err
+}
+
+func (b *redisBackend) Publish(ctx context.Context, channel string, payload []byte) error {
+	return b.client.Publish(ctx, channel, payload).Err()
+}
+
+func (b *redisBackend) Subscribe(channel string, handle func(payload []byte), reset func()) io.Closer {
+	s := &redisSubscription{
+		pubsub: b.client.Subscribe(context.Background(), channel),
+		done:   make(chan struct{}),
 	}
+	go s.run(handle, reset)
 
-	return nil
+	return s
 }
 
-func (c *Cache) Flush(ctx context.Context) error {
-	_, err := c.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
-		for _, v := range c.tags {
-			members, err := c.redis.SMembers(ctx, c.prefix+":"+v).Result()
-			if err != nil {
-				fmt.Println("c.redis.SMembers err:", err)
-				return err
-			}
+func (b *redisBackend) Close() error {
+	return b.client.Close()
+}
 
-			if len(members) > 0 {
-				err = p.Del(ctx, members...).Err()
-				if err != nil {
-					fmt.Println("p.Del err:", err)
-					return err
-				}
-			}
+type redisSubscription struct {
+	pubsub *redis.PubSub
+	done   chan struct{}
+	once   sync.Once
+}
 
-			err = p.Del(ctx, c.prefix+":"+v).Err()
-			if err != nil {
-				fmt.Println("p.Del err:", err)
-				return err
+func (s *redisSubscription) run(handle func(payload []byte), reset func()) {
+	ctx := context.Background()
+
+	for {
+		msg, err := s.pubsub.Receive(ctx)
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
 			}
+			// Messages may have been missed while disconnected
+			reset()
+			time.Sleep(time.Second)
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			// (Re)subscribed, anything published in between is lost
+			reset()
+		case *redis.Message:
+			handle([]byte(m.Payload))
 		}
-		return nil
-	})
-	if err != nil {
-		return err
 	}
-	return nil
+}
+
+func (s *redisSubscription) Close() error {
+	s.once.Do(func() { close(s.done) })
+
+	return s.pubsub.Close()
 }
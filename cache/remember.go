@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Version of the entry envelope, entries of other versions are misses
+const entryVersion = 1
+
+// entry is the stored form of a remembered value, the envelope lets a
+// cached null or empty value be told apart from a miss
+type entry[T any] struct {
+	Version int `json:"ver"`
+	Value   T   `json:"v"`
+	// Time fn took to compute the value, in milliseconds
+	Delta int64 `json:"d"`
+	// Logical expiry in unix milliseconds, 0 when the key never expires
+	Expiry int64 `json:"e"`
+}
+
+// Remember returns the value cached under key, or computes it with fn and
+// caches it for ttl under the given tags. A ttl of 0 uses the cache default.
+// Concurrent misses for the same key share a single call to fn and receive
+// the same value, which callers must treat as read-only.
+func Remember[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, tags []string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, span := tracer.Start(ctx, "CacheRemember", trace.WithAttributes(attribute.String("key", key), attribute.StringSlice("tags", tags)))
+	defer span.End()
+
+	var zero T
+
+	if ttl <= 0 {
+		ttl = c.expired
+	}
+	key = c.key(key)
+
+	cached, err := lookup[T](ctx, c, key)
+	if err != nil {
+		return zero, err
+	}
+
+	if cached != nil && !c.refreshEarly(cached.Delta, cached.Expiry) {
+		return cached.Value, nil
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		start := time.Now()
+		val, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		e := entry[T]{Version: entryVersion, Value: val, Delta: time.Since(start).Milliseconds()}
+		if ttl > 0 {
+			e.Expiry = time.Now().Add(ttl).UnixMilli()
+		}
+		raw, err := c.encode(e)
+		if err != nil {
+			fmt.Println("c.encode err:", err)
+			return nil, err
+		}
+
+		if err := c.Tag(tags...).store(ctx, key, raw, ttl); err != nil {
+			return nil, err
+		}
+
+		return val, nil
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	val, _ := v.(T)
+	return val, nil
+}
+
+// lookup reads a remembered entry, nil on a miss. Values that are not an
+// entry (for example written by Set) are misses and get overwritten.
+func lookup[T any](ctx context.Context, c *Cache, key string) (*entry[T], error) {
+	raw, err := c.read(ctx, key)
+	if err != nil {
+		if err == ErrMiss {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var e entry[T]
+	if err := c.decode(raw, &e); err != nil || e.Version != entryVersion {
+		return nil, nil
+	}
+
+	return &e, nil
+}
+
+// refreshEarly implements XFetch: the closer an entry is to expiry and the
+// longer it took to compute, the more likely a request recomputes it early
+// so that a hot key does not expire for every caller at once
+func (c *Cache) refreshEarly(delta int64, expiry int64) bool {
+	if c.beta <= 0 || expiry == 0 || delta <= 0 {
+		return false
+	}
+
+	gap := -float64(delta) * c.beta * math.Log(rand.Float64())
+
+	return float64(time.Now().UnixMilli())+gap >= float64(expiry)
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRememberCachesEmptyValues(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+
+	var calls int32
+	fn := func(ctx context.Context) ([]string, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		val, err := Remember(ctx, cacher, "empty", 0, nil, fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != nil {
+			t.Fatalf("val = %v, want nil", val)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestRememberDoesNotCacheErrors(t *testing.T) {
+	cacher, server := newTestCache(t)
+	ctx := context.Background()
+
+	_, err := Remember(ctx, cacher, "failing", 0, nil, func(ctx context.Context) (int, error) {
+		return 0, errors.New("boom")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if server.Exists("test:failing") {
+		t.Fatal("failed result was cached")
+	}
+}
+
+func TestRememberCollapsesConcurrentMisses(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+
+	var (
+		calls   int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	fn := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := Remember(ctx, cacher, "shared", 0, []string{"numbers"}, fn)
+			if err != nil {
+				t.Error(err)
+			}
+			if val != 42 {
+				t.Errorf("val = %d, want 42", val)
+			}
+		}()
+	}
+
+	// Give every goroutine time to reach the in-flight call
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestRememberTagsAreFlushed(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+
+	var calls int32
+	fn := func(ctx context.Context) (int, error) {
+		return int(atomic.AddInt32(&calls, 1)), nil
+	}
+
+	if _, err := Remember(ctx, cacher, "tagged", 0, []string{"numbers"}, fn); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacher.Tag("numbers").Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := Remember(ctx, cacher, "tagged", 0, []string{"numbers"}, fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != 2 {
+		t.Fatalf("val = %d, want 2 after flush", val)
+	}
+}
+
+func TestRefreshEarly(t *testing.T) {
+	cacher, _ := newTestCache(t)
+
+	expired := time.Now().Add(-time.Second).UnixMilli()
+	fresh := time.Now().Add(time.Hour).UnixMilli()
+
+	if cacher.refreshEarly(100, expired) {
+		t.Fatal("early refresh must be disabled without beta")
+	}
+
+	cacher.beta = 1
+	if !cacher.refreshEarly(100, expired) {
+		t.Fatal("entry past its expiry must refresh")
+	}
+	if cacher.refreshEarly(1, fresh) {
+		t.Fatal("fresh cheap entry must not refresh")
+	}
+}
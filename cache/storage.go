@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Namespace of responses stored by Storage below the cache prefix
+const storageNamespace = "response:"
+
+// Storage lets fiber middlewares such as the response cache keep their
+// entries in the cache. Entries are added to the tags of the cache view, so
+// flushing those tags also drops them.
+type Storage struct {
+	cache *Cache
+}
+
+// NewStorage returns a fiber.Storage backed by the cache, use Tag first to
+// tag the stored entries
+func NewStorage(c *Cache) *Storage {
+	return &Storage{cache: c}
+}
+
+// Get returns nil without an error for missing keys, as fiber expects
+func (s *Storage) Get(key string) ([]byte, error) {
+	raw, err := s.cache.read(context.Background(), s.cache.key(storageNamespace+key))
+	if err == ErrMiss {
+		return nil, nil
+	}
+
+	return raw, err
+}
+
+// Set stores the raw value, a zero expiration uses the cache default
+func (s *Storage) Set(key string, val []byte, exp time.Duration) error {
+	if len(key) == 0 || len(val) == 0 {
+		return nil
+	}
+	if exp == 0 {
+		exp = s.cache.expired
+	}
+
+	return s.cache.store(context.Background(), s.cache.key(storageNamespace+key), val, exp)
+}
+
+func (s *Storage) Delete(key string) error {
+	ctx := context.Background()
+	key = s.cache.key(storageNamespace + key)
+	if err := s.cache.backend.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.cache.publish(ctx, key)
+
+	return nil
+}
+
+// Reset flushes the tags of the cache view
+func (s *Storage) Reset() error {
+	return s.cache.Flush(context.Background())
+}
+
+// Close is a no-op, the cache is closed by its owner
+func (s *Storage) Close() error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	fibercache "github.com/gofiber/fiber/v2/middleware/cache"
+)
+
+func TestStorageMissReturnsNil(t *testing.T) {
+	cacher, _ := newTestCache(t)
+
+	raw, err := NewStorage(cacher).Get("missing")
+	if err != nil || raw != nil {
+		t.Fatalf("Get = %q, %v, want nil, nil", raw, err)
+	}
+}
+
+func TestStorageStoresRawBytes(t *testing.T) {
+	cacher, server := newTestCache(t)
+	storage := NewStorage(cacher)
+
+	if err := storage.Set("GET:/users", []byte("raw body"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := storage.Get("GET:/users")
+	if err != nil || !bytes.Equal(raw, []byte("raw body")) {
+		t.Fatalf("Get = %q, %v, want raw body", raw, err)
+	}
+	if !server.Exists("test:response:GET:/users") {
+		t.Fatalf("keys = %v, want the response namespace", server.Keys())
+	}
+
+	if err := storage.Delete("GET:/users"); err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := storage.Get("GET:/users"); raw != nil {
+		t.Fatalf("Get after Delete = %q, want nil", raw)
+	}
+}
+
+func TestStorageEntriesAreFlushedWithTags(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	ctx := context.Background()
+	storage := NewStorage(cacher.Tag("users"))
+
+	if err := storage.Set("GET:/users", []byte("page"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacher.Tag("users").Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if raw, _ := storage.Get("GET:/users"); raw != nil {
+		t.Fatalf("Get after flushing the tag = %q, want nil", raw)
+	}
+}
+
+func TestResponseCacheReadAfterWrite(t *testing.T) {
+	cacher, _ := newTestCache(t)
+	version := 1
+
+	app := fiber.New()
+	app.Get("/users", fibercache.New(fibercache.Config{Storage: NewStorage(cacher.Tag("users")), Expiration: time.Minute}), func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(version))
+	})
+	// Writes flush the tag of the cached responses, as the user handlers do
+	app.Post("/users", func(c *fiber.Ctx) error {
+		version++
+		return cacher.Tag("users").Flush(c.Context())
+	})
+
+	read := func() string {
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/users", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		return string(body)
+	}
+
+	if got := read(); got != "1" {
+		t.Fatalf("first read = %s, want 1", got)
+	}
+	if got := read(); got != "1" {
+		t.Fatalf("cached read = %s, want 1", got)
+	}
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/users", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != "2" {
+		t.Fatalf("read after write = %s, want 2", got)
+	}
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
@@ -13,9 +15,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/csrf"
 	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/utils"
 	"github.com/joho/godotenv"
 )
 
@@ -25,10 +25,16 @@ type config struct {
 	Env         string
 	Port        string
 	ServiceName string
+	AppURL      string
+	AppKey      string
 	// Fiber settings
 	IsPrefork        string
 	CorsAllowOrigins string
-	RateLimit        int
+	// Sliding window rate limits, per IP for anonymous callers and per subject for users
+	RateLimit              int
+	RateLimitPrincipal     int
+	RateLimitAuth          int
+	RateLimitWindowSeconds int
 	// Database
 	DatabaseDSN          string
 	DatabaseURL          string
@@ -39,12 +45,31 @@ type config struct {
 	DatabasePassword     string
 	DatabaseMaxIdleConns int
 	DatabaseMaxOpenConns int
+	// Read replicas, reads go to the primary for a while after a caller writes
+	DatabaseReplicaDSNs           []string
+	DatabaseReplicaPolicy         string
+	DatabaseReadYourWritesSeconds int
+	// Migrations are embedded unless MigrationsPath overrides them with a directory
+	MigrationsPath   string
+	MigrationsStrict bool
+	// Largest page size list endpoints return
+	PaginationMaxLimit int
 	// Cache driver
-	RedisAddr           string
-	RedisUsername       string
-	RedisPassword       string
-	CachePrefix         string
-	CacheMinuteDuration int
+	CacheDriver           string
+	RedisAddr             string
+	RedisAddrs            []string
+	RedisMasterName       string
+	RedisSentinelPassword string
+	RedisUsername         string
+	RedisPassword         string
+	RedisPrefix           string
+	CachePrefix           string
+	CacheMinuteDuration   int
+	CacheEarlyRefresh     float64
+	CacheLocalMaxBytes    int
+	CacheLocalSeconds     int
+	CacheCodec            string
+	CacheCompressAbove    int
 	// Sentry.io
 	SentryDSN              string
 	SentryEnableTracing    bool
@@ -53,7 +78,38 @@ type config struct {
 	OtelExporterOTLPEndpoint string
 	OtelInsecureMode         bool
 	// OAuth Public Key
-	OAuthPublicKey string
+	OAuthPublicKey        string
+	JWTMaxLifetimeMinutes int
+	// First-party login
+	OAuthPrivateKey            string
+	AccessTokenLifetimeMinutes int
+	// Multi-factor authentication
+	MFAEncryptionKey string
+	MFARequiredRoles []string
+	PrivilegedScopes []string
+	// API keys
+	APIKeyRateLimit            int
+	APIKeyRotationGraceMinutes int
+	// Account emails
+	MailDriver                  string
+	MailFrom                    string
+	SMTPHost                    string
+	SMTPPort                    string
+	SMTPUsername                string
+	SMTPPassword                string
+	EmailVerificationTTLMinutes int
+	PasswordResetTTLMinutes     int
+	AccountEmailRateLimit       int
+	AccountIPRateLimit          int
+	// Login brute-force protection
+	LoginAttemptWindowMinutes int
+	LoginDelayAfter           int
+	LoginMaxDelaySeconds      int
+	LoginMaxAttempts          int
+	LoginLockoutMinutes       int
+	LoginIPMaxAttempts        int
+	// Idempotency-Key replay
+	IdempotencyTTLHours int
 }
 
 var (
@@ -64,7 +120,6 @@ var (
 	CsrfConfig    csrf.Config
 	LoggerConfig  logger.Config
 	FaviconConfig favicon.Config
-	LimiterConfig limiter.Config
 	CacheConfig   cache.Config
 )
 
@@ -78,6 +133,8 @@ func LoadConfig() {
 		AppName:          os.Getenv("APP_NAME"),
 		ServiceName:      os.Getenv("SERVICE_NAME"),
 		Port:             os.Getenv("PORT"),
+		AppURL:           os.Getenv("APP_URL"),
+		AppKey:           os.Getenv("APP_KEY"),
 		IsPrefork:        os.Getenv("FIBER_PREFORK"),
 		CorsAllowOrigins: os.Getenv("CORS_ALLOW_ORIGINS"),
 		// Database
@@ -86,18 +143,33 @@ func LoadConfig() {
 		DatabaseName:     os.Getenv("DATABASE_NAME"),
 		DatabaseUser:     os.Getenv("DATABASE_USER"),
 		DatabasePassword: os.Getenv("DATABASE_PASSWORD"),
+		MigrationsPath:   os.Getenv("MIGRATIONS_PATH"),
 		// Redis
-		RedisAddr:     os.Getenv("REDIS_ADDR"),
-		RedisUsername: os.Getenv("REDIS_USERNAME"),
-		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+		RedisAddr:             os.Getenv("REDIS_ADDR"),
+		RedisMasterName:       os.Getenv("REDIS_MASTER_NAME"),
+		RedisSentinelPassword: os.Getenv("REDIS_SENTINEL_PASSWORD"),
+		RedisUsername:         os.Getenv("REDIS_USERNAME"),
+		RedisPassword:         os.Getenv("REDIS_PASSWORD"),
+		RedisPrefix:           os.Getenv("REDIS_PREFIX"),
 		// Cache settings
 		CachePrefix: os.Getenv("CACHE_PREFIX"),
+		CacheCodec:  os.Getenv("CACHE_CODEC"),
 		// Sentry.io
 		SentryDSN: os.Getenv("SENTRY_DSN"),
 		// OpenTelemetry settings
 		OtelExporterOTLPEndpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
 		// OAuth Public Key
-		OAuthPublicKey: os.Getenv("OAUTH_PUBLIC_KEY"),
+		OAuthPublicKey:  os.Getenv("OAUTH_PUBLIC_KEY"),
+		OAuthPrivateKey: os.Getenv("OAUTH_PRIVATE_KEY"),
+		// Multi-factor authentication
+		MFAEncryptionKey: os.Getenv("MFA_ENCRYPTION_KEY"),
+		// Account emails
+		MailDriver:   os.Getenv("MAIL_DRIVER"),
+		MailFrom:     os.Getenv("MAIL_FROM"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     os.Getenv("SMTP_PORT"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
 	}
 
 	// Build database DSN
@@ -124,10 +196,34 @@ func LoadConfig() {
 	if err == nil {
 		AppConfig.RateLimit = rateLimiter
 	} else {
-		// Default cache duration is 60 seconds
+		// Default is 60 requests per window for each IP
 		AppConfig.RateLimit = 60
 	}
 
+	rateLimitPrincipal, err := strconv.Atoi(os.Getenv("RATE_LIMIT_PRINCIPAL"))
+	if err == nil {
+		AppConfig.RateLimitPrincipal = rateLimitPrincipal
+	} else {
+		// Default is 300 requests per window for each user
+		AppConfig.RateLimitPrincipal = 300
+	}
+
+	rateLimitAuth, err := strconv.Atoi(os.Getenv("RATE_LIMIT_AUTH"))
+	if err == nil {
+		AppConfig.RateLimitAuth = rateLimitAuth
+	} else {
+		// Default is 20 requests per window to the auth routes for each IP
+		AppConfig.RateLimitAuth = 20
+	}
+
+	rateLimitWindowSeconds, err := strconv.Atoi(os.Getenv("RATE_LIMIT_WINDOW_SECONDS"))
+	if err == nil && rateLimitWindowSeconds > 0 {
+		AppConfig.RateLimitWindowSeconds = rateLimitWindowSeconds
+	} else {
+		// Default window is 60 seconds
+		AppConfig.RateLimitWindowSeconds = 60
+	}
+
 	databaseMaxIdleConns, err := strconv.Atoi(os.Getenv("DATABASE_MAX_IDLE_CONNS"))
 	if err == nil {
 		AppConfig.DatabaseMaxIdleConns = databaseMaxIdleConns
@@ -141,7 +237,72 @@ func LoadConfig() {
 		AppConfig.DatabaseMaxOpenConns = databaseMaxOpenConns
 	} else {
 		// Default max open conns is 20
-		AppConfig.DatabaseMaxIdleConns = 20
+		AppConfig.DatabaseMaxOpenConns = 20
+	}
+
+	// Replica hosts as host or host:port, sharing the credentials of the primary
+	if replicaHosts := os.Getenv("DATABASE_REPLICA_HOSTS"); replicaHosts != "" {
+		for _, replicaHost := range strings.Split(replicaHosts, ",") {
+			host, port, found := strings.Cut(strings.TrimSpace(replicaHost), ":")
+			if !found {
+				port = AppConfig.DatabasePort
+			}
+			AppConfig.DatabaseReplicaDSNs = append(AppConfig.DatabaseReplicaDSNs, fmt.Sprintf(
+				`host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Bangkok`,
+				host,
+				AppConfig.DatabaseUser,
+				AppConfig.DatabasePassword,
+				AppConfig.DatabaseName,
+				port,
+			))
+		}
+	}
+
+	// Replica policy is random or latency
+	if replicaPolicy := os.Getenv("DATABASE_REPLICA_POLICY"); replicaPolicy != "" {
+		AppConfig.DatabaseReplicaPolicy = replicaPolicy
+	} else {
+		AppConfig.DatabaseReplicaPolicy = "random"
+	}
+
+	databaseReadYourWritesSeconds, err := strconv.Atoi(os.Getenv("DATABASE_READ_YOUR_WRITES_SECONDS"))
+	if err == nil {
+		AppConfig.DatabaseReadYourWritesSeconds = databaseReadYourWritesSeconds
+	} else {
+		// Default is 5 seconds of reads from the primary after a write
+		AppConfig.DatabaseReadYourWritesSeconds = 5
+	}
+
+	migrationsStrict, err := strconv.ParseBool(os.Getenv("MIGRATIONS_STRICT"))
+	if err == nil {
+		AppConfig.MigrationsStrict = migrationsStrict
+	} else {
+		// Default is false, a schema version mismatch is only logged
+		AppConfig.MigrationsStrict = false
+	}
+
+	paginationMaxLimit, err := strconv.Atoi(os.Getenv("PAGINATION_MAX_LIMIT"))
+	if err == nil {
+		AppConfig.PaginationMaxLimit = paginationMaxLimit
+	} else {
+		// Default max page size is 100 rows
+		AppConfig.PaginationMaxLimit = 100
+	}
+
+	// Cache driver is one of redis, cluster, sentinel or memory. The memory
+	// driver only replaces the cache, sessions and limits still use Redis at
+	// REDIS_ADDR and the service does not start without it
+	if cacheDriver := os.Getenv("CACHE_DRIVER"); cacheDriver != "" {
+		AppConfig.CacheDriver = cacheDriver
+	} else {
+		AppConfig.CacheDriver = "redis"
+	}
+
+	// Cluster nodes or Sentinel addresses, defaults to REDIS_ADDR
+	if redisAddrs := os.Getenv("REDIS_ADDRS"); redisAddrs != "" {
+		AppConfig.RedisAddrs = strings.Split(redisAddrs, ",")
+	} else {
+		AppConfig.RedisAddrs = []string{AppConfig.RedisAddr}
 	}
 
 	cacheMinuteDuration, err := strconv.Atoi(os.Getenv("CACHE_MINUTE_DURATION"))
@@ -152,6 +313,39 @@ func LoadConfig() {
 		AppConfig.CacheMinuteDuration = 5
 	}
 
+	cacheEarlyRefresh, err := strconv.ParseFloat(os.Getenv("CACHE_EARLY_REFRESH_BETA"), 64)
+	if err == nil {
+		AppConfig.CacheEarlyRefresh = cacheEarlyRefresh
+	} else {
+		// Default disables probabilistic early refresh
+		AppConfig.CacheEarlyRefresh = 0
+	}
+
+	cacheLocalMaxBytes, err := strconv.Atoi(os.Getenv("CACHE_LOCAL_MAX_BYTES"))
+	if err == nil {
+		AppConfig.CacheLocalMaxBytes = cacheLocalMaxBytes
+	} else {
+		// Default disables the in-process cache tier
+		AppConfig.CacheLocalMaxBytes = 0
+	}
+
+	cacheLocalSeconds, err := strconv.Atoi(os.Getenv("CACHE_LOCAL_TTL_SECONDS"))
+	if err == nil {
+		AppConfig.CacheLocalSeconds = cacheLocalSeconds
+	} else {
+		// Default in-process cache TTL is 30 seconds
+		AppConfig.CacheLocalSeconds = 30
+	}
+
+	cacheCompressAbove, err := strconv.Atoi(os.Getenv("CACHE_COMPRESS_THRESHOLD"))
+	if err == nil {
+		AppConfig.CacheCompressAbove = cacheCompressAbove
+	} else {
+		// Default disables compression, instances older than the codec
+		// support cannot read compressed values
+		AppConfig.CacheCompressAbove = 0
+	}
+
 	var isPrefork bool
 	if AppConfig.IsPrefork == "true" {
 		isPrefork = true
@@ -183,25 +377,37 @@ func LoadConfig() {
 		File: "",
 	}
 
-	LimiterConfig = limiter.Config{
-		Max: AppConfig.RateLimit,
-		Next: func(c *fiber.Ctx) bool {
-			return c.Query("loadtest") == "true"
-		},
-	}
-
 	CacheConfig = cache.Config{
+		// Clients skip cached responses with Cache-Control: no-cache, only
+		// successful and shareable responses are stored
 		Next: func(c *fiber.Ctx) bool {
-			return c.Query("refresh") == "true"
+			cacheControl := c.GetRespHeader(fiber.HeaderCacheControl)
+			return c.Response().StatusCode() != fiber.StatusOK ||
+				strings.Contains(cacheControl, "private") ||
+				strings.Contains(cacheControl, "no-store")
 		},
-		CacheHeader:  "X-Cache",
-		Expiration:   time.Duration(AppConfig.CacheMinuteDuration) * time.Minute,
-		CacheControl: true,
+		CacheHeader: "X-Cache",
+		Expiration:  time.Duration(AppConfig.CacheMinuteDuration) * time.Minute,
 		KeyGenerator: func(c *fiber.Ctx) string {
-			return utils.CopyString(c.Path())
+			// Encode sorts the parameters, so their order does not split the cache
+			query := url.Values{}
+			c.Request().URI().QueryArgs().VisitAll(func(key, value []byte) {
+				query.Add(string(key), string(value))
+			})
+			return c.Method() + ":" + c.Path() + "?" + query.Encode()
 		},
 	}
 
+	// Default cache prefix is cache
+	if AppConfig.CachePrefix == "" {
+		AppConfig.CachePrefix = "cache"
+	}
+	// The service keys never share the cache namespace, the cache admin
+	// endpoints must not reach them
+	if AppConfig.RedisPrefix == "" || AppConfig.RedisPrefix == AppConfig.CachePrefix {
+		AppConfig.RedisPrefix = AppConfig.CachePrefix + "-state"
+	}
+
 	// Set CORS default to allow all
 	if AppConfig.CorsAllowOrigins == "" {
 		AppConfig.CorsAllowOrigins = "*"
@@ -236,4 +442,137 @@ func LoadConfig() {
 		// Default is false
 		AppConfig.OtelInsecureMode = false
 	}
+
+	jwtMaxLifetimeMinutes, err := strconv.Atoi(os.Getenv("JWT_MAX_LIFETIME_MINUTES"))
+	if err == nil {
+		AppConfig.JWTMaxLifetimeMinutes = jwtMaxLifetimeMinutes
+	} else {
+		// Default is 24 hours, the longest lifetime of an issued access token
+		AppConfig.JWTMaxLifetimeMinutes = 1440
+	}
+
+	accessTokenLifetimeMinutes, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_LIFETIME_MINUTES"))
+	if err == nil {
+		AppConfig.AccessTokenLifetimeMinutes = accessTokenLifetimeMinutes
+	} else {
+		// Default is 60 minutes
+		AppConfig.AccessTokenLifetimeMinutes = 60
+	}
+
+	// Roles that must have MFA enabled before their tokens carry privileged scopes
+	if mfaRequiredRoles := os.Getenv("MFA_REQUIRED_ROLES"); mfaRequiredRoles != "" {
+		AppConfig.MFARequiredRoles = strings.Split(mfaRequiredRoles, ",")
+	} else {
+		AppConfig.MFARequiredRoles = []string{"staff", "admin"}
+	}
+
+	if privilegedScopes := os.Getenv("PRIVILEGED_SCOPES"); privilegedScopes != "" {
+		AppConfig.PrivilegedScopes = strings.Split(privilegedScopes, ",")
+	} else {
+		AppConfig.PrivilegedScopes = []string{"payments:refund", "admin"}
+	}
+
+	emailVerificationTTLMinutes, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_TTL_MINUTES"))
+	if err == nil {
+		AppConfig.EmailVerificationTTLMinutes = emailVerificationTTLMinutes
+	} else {
+		// Default is 24 hours
+		AppConfig.EmailVerificationTTLMinutes = 1440
+	}
+
+	passwordResetTTLMinutes, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_TTL_MINUTES"))
+	if err == nil {
+		AppConfig.PasswordResetTTLMinutes = passwordResetTTLMinutes
+	} else {
+		// Default is 30 minutes
+		AppConfig.PasswordResetTTLMinutes = 30
+	}
+
+	accountEmailRateLimit, err := strconv.Atoi(os.Getenv("ACCOUNT_EMAIL_RATE_LIMIT"))
+	if err == nil {
+		AppConfig.AccountEmailRateLimit = accountEmailRateLimit
+	} else {
+		// Default is 3 emails per hour for each address
+		AppConfig.AccountEmailRateLimit = 3
+	}
+
+	accountIPRateLimit, err := strconv.Atoi(os.Getenv("ACCOUNT_IP_RATE_LIMIT"))
+	if err == nil {
+		AppConfig.AccountIPRateLimit = accountIPRateLimit
+	} else {
+		// Default is 10 requests per hour for each IP
+		AppConfig.AccountIPRateLimit = 10
+	}
+
+	loginAttemptWindowMinutes, err := strconv.Atoi(os.Getenv("LOGIN_ATTEMPT_WINDOW_MINUTES"))
+	if err == nil {
+		AppConfig.LoginAttemptWindowMinutes = loginAttemptWindowMinutes
+	} else {
+		// Default forgets failed attempts after 15 quiet minutes
+		AppConfig.LoginAttemptWindowMinutes = 15
+	}
+
+	loginDelayAfter, err := strconv.Atoi(os.Getenv("LOGIN_DELAY_AFTER"))
+	if err == nil {
+		AppConfig.LoginDelayAfter = loginDelayAfter
+	} else {
+		// Default starts delaying after 3 failed attempts
+		AppConfig.LoginDelayAfter = 3
+	}
+
+	loginMaxDelaySeconds, err := strconv.Atoi(os.Getenv("LOGIN_MAX_DELAY_SECONDS"))
+	if err == nil {
+		AppConfig.LoginMaxDelaySeconds = loginMaxDelaySeconds
+	} else {
+		// Default is 60 seconds
+		AppConfig.LoginMaxDelaySeconds = 60
+	}
+
+	loginMaxAttempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS"))
+	if err == nil {
+		AppConfig.LoginMaxAttempts = loginMaxAttempts
+	} else {
+		// Default locks the account after 10 failed attempts
+		AppConfig.LoginMaxAttempts = 10
+	}
+
+	loginLockoutMinutes, err := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_MINUTES"))
+	if err == nil {
+		AppConfig.LoginLockoutMinutes = loginLockoutMinutes
+	} else {
+		// Default is 30 minutes
+		AppConfig.LoginLockoutMinutes = 30
+	}
+
+	loginIPMaxAttempts, err := strconv.Atoi(os.Getenv("LOGIN_IP_MAX_ATTEMPTS"))
+	if err == nil {
+		AppConfig.LoginIPMaxAttempts = loginIPMaxAttempts
+	} else {
+		// Default blocks an IP after 50 failed attempts across all accounts
+		AppConfig.LoginIPMaxAttempts = 50
+	}
+
+	apiKeyRateLimit, err := strconv.Atoi(os.Getenv("API_KEY_RATE_LIMIT"))
+	if err == nil {
+		AppConfig.APIKeyRateLimit = apiKeyRateLimit
+	} else {
+		// Default is 120 requests per minute for each key
+		AppConfig.APIKeyRateLimit = 120
+	}
+
+	apiKeyRotationGraceMinutes, err := strconv.Atoi(os.Getenv("API_KEY_ROTATION_GRACE_MINUTES"))
+	if err == nil {
+		AppConfig.APIKeyRotationGraceMinutes = apiKeyRotationGraceMinutes
+	} else {
+		// Default keeps a rotated key valid for 60 minutes
+		AppConfig.APIKeyRotationGraceMinutes = 60
+	}
+
+	idempotencyTTLHours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS"))
+	if err == nil {
+		AppConfig.IdempotencyTTLHours = idempotencyTTLHours
+	} else {
+		// Default replays responses for 24 hours
+		AppConfig.IdempotencyTTLHours = 24
+	}
 }
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
+)
+
+// auditKey is the context key of the AuditActor of a request
+type auditKey struct{}
+
+// AuditKey stores the AuditActor of a request in the fiber locals or a context
+var AuditKey = auditKey{}
+
+// AuditActor is who made the changes of a request
+type AuditActor struct {
+	Type      string
+	ID        string
+	RequestID string
+	IP        string
+}
+
+// systemActor makes the changes outside of a request, e.g. the seeders
+var systemActor = AuditActor{Type: "system"}
+
+const (
+	auditRedacted  = "[redacted]"
+	auditBeforeKey = "database:audit_before"
+)
+
+var (
+	// auditSkipTables are logs themselves
+	auditSkipTables = map[string]bool{"audit_logs": true, "security_events": true}
+	// auditIgnoredColumns alone don't make an update worth auditing
+	auditIgnoredColumns = map[string]bool{"updated_at": true, "last_used_at": true}
+)
+
+// registerAudit writes an audit log entry for every row created, updated or
+// deleted through a model, in the same transaction as the change. Updates
+// and deletes read the rows before the change, raw SQL is not audited.
+func registerAudit(db *gorm.DB) error {
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").Register("database:audit", auditCreate),
+		callback.Update().After("gorm:begin_transaction").Before("gorm:update").Register("database:audit_before", auditBefore),
+		callback.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").Register("database:audit", auditUpdate),
+		callback.Delete().After("gorm:begin_transaction").Before("gorm:delete").Register("database:audit_before", auditBefore),
+		callback.Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").Register("database:audit", auditDelete),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func auditable(db *gorm.DB) bool {
+	return db.Error == nil && !db.DryRun && db.Statement.Schema != nil && db.Statement.Schema.PrioritizedPrimaryField != nil &&
+		!auditSkipTables[db.Statement.Table]
+}
+
+func auditCreate(db *gorm.DB) {
+	if !auditable(db) || db.RowsAffected == 0 {
+		return
+	}
+
+	var entries []models.AuditLog
+	appendRow := func(row reflect.Value) {
+		after := map[string]interface{}{}
+		for _, field := range db.Statement.Schema.Fields {
+			if field.DBName == "" || !field.Readable {
+				continue
+			}
+			value, _ := field.ValueOf(db.Statement.Context, row)
+			after[field.DBName] = auditValue(db.Statement.Schema, field.DBName, value)
+		}
+		entries = append(entries, auditEntry(db, models.AuditActionCreate, after, nil, after))
+	}
+
+	switch reflectValue := reflect.Indirect(db.Statement.ReflectValue); reflectValue.Kind() {
+	case reflect.Struct:
+		appendRow(reflectValue)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < reflectValue.Len(); i++ {
+			appendRow(reflect.Indirect(reflectValue.Index(i)))
+		}
+	}
+
+	writeAudit(db, entries)
+}
+
+// auditBefore reads the rows an update or delete is about to change
+func auditBefore(db *gorm.DB) {
+	if !auditable(db) {
+		return
+	}
+
+	exprs := auditConditions(db)
+	if len(exprs) == 0 {
+		return
+	}
+
+	var rows []map[string]interface{}
+	query := auditQuery(db).Where(clause.Where{Exprs: exprs})
+	if db.Statement.Unscoped {
+		query = query.Unscoped()
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(auditBeforeKey, rows)
+}
+
+func auditUpdate(db *gorm.DB) {
+	before := auditRowsBefore(db)
+	if len(before) == 0 {
+		return
+	}
+
+	// Read the rows again by their primary key, the update may have changed the conditions
+	primaryKey := db.Statement.Schema.PrioritizedPrimaryField
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[primaryKey.DBName])
+	}
+	var rows []map[string]interface{}
+	if err := auditQuery(db).Unscoped().Where(clause.IN{Column: clause.Column{Name: primaryKey.DBName}, Values: ids}).Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+	after := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		after[fmt.Sprint(row[primaryKey.DBName])] = row
+	}
+
+	var entries []models.AuditLog
+	for _, beforeRow := range before {
+		afterRow := after[fmt.Sprint(beforeRow[primaryKey.DBName])]
+		changedBefore, changedAfter := map[string]interface{}{}, map[string]interface{}{}
+		changed := false
+		for column, value := range afterRow {
+			if reflect.DeepEqual(beforeRow[column], value) {
+				continue
+			}
+			changedBefore[column] = auditValue(db.Statement.Schema, column, beforeRow[column])
+			changedAfter[column] = auditValue(db.Statement.Schema, column, value)
+			changed = changed || !auditIgnoredColumns[column]
+		}
+		if changed {
+			entries = append(entries, auditEntry(db, models.AuditActionUpdate, beforeRow, changedBefore, changedAfter))
+		}
+	}
+
+	writeAudit(db, entries)
+}
+
+func auditDelete(db *gorm.DB) {
+	before := auditRowsBefore(db)
+
+	entries := make([]models.AuditLog, 0, len(before))
+	for _, row := range before {
+		for column, value := range row {
+			row[column] = auditValue(db.Statement.Schema, column, value)
+		}
+		entries = append(entries, auditEntry(db, models.AuditActionDelete, row, row, nil))
+	}
+
+	writeAudit(db, entries)
+}
+
+func auditRowsBefore(db *gorm.DB) []map[string]interface{} {
+	if !auditable(db) || db.RowsAffected == 0 {
+		return nil
+	}
+	rows, _ := db.InstanceGet(auditBeforeKey)
+	before, _ := rows.([]map[string]interface{})
+
+	return before
+}
+
+// auditQuery reads rows of the statement's model from the primary, in the
+// transaction of the statement
+func auditQuery(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Clauses(dbresolver.Write).
+		Model(reflect.New(db.Statement.Schema.ModelType).Interface())
+}
+
+// auditConditions are the conditions of an update or delete, with the primary
+// key of the model for saves and deletes of a loaded row
+func auditConditions(db *gorm.DB) []clause.Expression {
+	var exprs []clause.Expression
+	if where, ok := db.Statement.Clauses["WHERE"].Expression.(clause.Where); ok {
+		exprs = append(exprs, where.Exprs...)
+	}
+
+	if reflectValue := reflect.Indirect(db.Statement.ReflectValue); reflectValue.Kind() == reflect.Struct {
+		for _, field := range db.Statement.Schema.PrimaryFields {
+			if value, isZero := field.ValueOf(db.Statement.Context, reflectValue); !isZero {
+				exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: value})
+			}
+		}
+	}
+
+	return exprs
+}
+
+// auditValue redacts the columns which the model hides from JSON, such as hashes and secrets
+func auditValue(modelSchema *schema.Schema, column string, value interface{}) interface{} {
+	if field := modelSchema.LookUpField(column); field != nil && field.Tag.Get("json") == "-" {
+		return auditRedacted
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		value, _ = valuer.Value()
+	}
+
+	return value
+}
+
+func auditEntry(db *gorm.DB, action string, row map[string]interface{}, before map[string]interface{}, after map[string]interface{}) models.AuditLog {
+	actor, ok := db.Statement.Context.Value(AuditKey).(*AuditActor)
+	if !ok {
+		actor = &systemActor
+	}
+
+	changes := map[string]interface{}{}
+	if before != nil {
+		changes["before"] = before
+	}
+	if after != nil {
+		changes["after"] = after
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		db.AddError(err)
+	}
+
+	return models.AuditLog{
+		Action:    action,
+		Entity:    db.Statement.Table,
+		EntityID:  fmt.Sprint(row[db.Statement.Schema.PrioritizedPrimaryField.DBName]),
+		ActorType: actor.Type,
+		ActorID:   actor.ID,
+		RequestID: actor.RequestID,
+		IP:        actor.IP,
+		Changes:   encoded,
+	}
+}
+
+func writeAudit(db *gorm.DB, entries []models.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+
+	if err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&entries).Error; err != nil {
+		db.AddError(err)
+	}
+}
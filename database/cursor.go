@@ -0,0 +1,305 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Count modes of cursor pagination, offset pagination always counts exactly
+const (
+	CountNone  = "none"
+	CountExact = "exact"
+	// CountEstimate reads the row estimate of the table from pg_class, it
+	// ignores filters and is only as fresh as the last ANALYZE
+	CountEstimate = "estimate"
+)
+
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// cursor is the opaque position of cursor pagination, the values of the
+// sort columns and the id of the row next to the requested page
+type cursor struct {
+	Sort      string        `json:"s"`
+	Direction string        `json:"d"`
+	Values    []interface{} `json:"v"`
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(encoded string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep numbers as sent, ids may not fit a float64
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var c cursor
+	if err = decoder.Decode(&c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// validateCursor reads the cursor of the request, it must come from a page with the same sort
+func (p *Pagination) validateCursor() error {
+	if p.Count == "" {
+		p.Count = CountNone
+	}
+	if p.Count != CountNone && p.Count != CountExact && p.Count != CountEstimate {
+		return &QueryError{Parameter: "count", Value: p.Count}
+	}
+
+	p.after = nil
+	if p.Cursor == "" {
+		return nil
+	}
+
+	after, err := decodeCursor(p.Cursor)
+	if err != nil || after.Sort != p.Sort || len(after.Values) != len(p.keyset()) ||
+		(after.Direction != cursorNext && after.Direction != cursorPrev) {
+		return &QueryError{Parameter: "cursor", Value: p.Cursor}
+	}
+	// Only scalars are written to cursors, objects, arrays and nulls were tampered with
+	for _, value := range after.Values {
+		switch value.(type) {
+		case json.Number, string, bool:
+		default:
+			return &QueryError{Parameter: "cursor", Value: p.Cursor}
+		}
+	}
+	p.after = after
+
+	return nil
+}
+
+// keyset is the sort with the id as a tie breaker, so every row has a unique position
+func (p *Pagination) keyset() []clause.OrderByColumn {
+	keyset := p.order
+	if len(keyset) == 0 {
+		return []clause.OrderByColumn{{Column: clause.Column{Name: "id"}, Desc: true}}
+	}
+
+	last := keyset[len(keyset)-1]
+	if last.Column.Name == "id" {
+		return keyset
+	}
+
+	return append(keyset[:len(keyset):len(keyset)], clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: last.Desc})
+}
+
+// backwards reports whether the page is read in reverse to go to the previous page
+func (p *Pagination) backwards() bool {
+	return p.after != nil && p.after.Direction == cursorPrev
+}
+
+// seek orders by the keyset and starts after the cursor, rows come in
+// reverse when going back and SetData puts them in order again
+func (p *Pagination) seek(db *gorm.DB) *gorm.DB {
+	keyset := p.keyset()
+	for _, order := range keyset {
+		order.Desc = order.Desc != p.backwards()
+		db = db.Order(order)
+	}
+
+	if p.after == nil {
+		return db
+	}
+
+	// (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?)
+	var conditions []clause.Expression
+	for i, order := range keyset {
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: keyset[j].Column, Value: p.after.Values[j]})
+		}
+		if order.Desc != p.backwards() {
+			and = append(and, clause.Lt{Column: order.Column, Value: p.after.Values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: order.Column, Value: p.after.Values[i]})
+		}
+		conditions = append(conditions, clause.And(and...))
+	}
+
+	return db.Where(clause.Or(conditions...))
+}
+
+// selectKeyset adds the keyset columns to the selected fields, the cursors are read from them
+func (p *Pagination) selectKeyset(selected []string) []string {
+	if len(selected) == 0 {
+		return selected
+	}
+
+	columns := append([]string(nil), selected...)
+	for _, order := range p.keyset() {
+		columns = append(columns, order.Column.Name)
+	}
+
+	return columns
+}
+
+// paginateCursor seeks past the cursor instead of scanning the skipped rows,
+// one more row than the limit is read to know whether there is a next page
+func paginateCursor[T any](query *gorm.DB, pagination *Pagination, page *Page[T]) (*Page[T], error) {
+	modelSchema, err := parseSchema(query, new(T))
+	if err != nil {
+		return nil, err
+	}
+	if err = pagination.typeCursor(modelSchema); err != nil {
+		return nil, err
+	}
+
+	switch pagination.Count {
+	case CountExact:
+		err = query.Scopes(pagination.filter).Count(&page.TotalRows).Error
+	case CountEstimate:
+		err = query.Session(&gorm.Session{NewDB: true}).
+			Raw(`SELECT GREATEST(reltuples, 0)::bigint FROM pg_class WHERE relname = ?`, modelSchema.Table).
+			Scan(&page.TotalRows).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	if page.TotalRows > 0 {
+		page.TotalPages = int(math.Ceil(float64(page.TotalRows) / float64(page.Limit)))
+	}
+
+	seek := query.Scopes(pagination.filter, pagination.seek).Limit(page.Limit + 1)
+	if selected := pagination.selectKeyset(pagination.selected); len(selected) > 0 {
+		seek = seek.Select(selected)
+	}
+	if err = seek.Find(&page.Data).Error; err != nil {
+		return nil, err
+	}
+
+	// Drop the row read to look ahead and put a page read backwards in order
+	more := len(page.Data) > page.Limit
+	if more {
+		page.Data = page.Data[:page.Limit]
+	}
+	if pagination.backwards() {
+		slices.Reverse(page.Data)
+	}
+
+	if len(page.Data) > 0 {
+		first, last := page.Data[0], page.Data[len(page.Data)-1]
+		// Going back, the page we came from follows this one
+		if more || pagination.backwards() {
+			page.NextCursor = pagination.cursorOf(modelSchema, reflect.ValueOf(last), cursorNext)
+		}
+		if (more && pagination.backwards()) || (pagination.after != nil && !pagination.backwards()) {
+			page.PrevCursor = pagination.cursorOf(modelSchema, reflect.ValueOf(first), cursorPrev)
+		}
+	}
+
+	return page, nil
+}
+
+// typeCursor converts the cursor values to the types of their sort columns,
+// a value that does not fit its column is rejected before it reaches the SQL
+func (p *Pagination) typeCursor(modelSchema *schema.Schema) error {
+	if p.after == nil {
+		return nil
+	}
+
+	for i, order := range p.keyset() {
+		field := modelSchema.LookUpField(order.Column.Name)
+		if field == nil {
+			return &QueryError{Parameter: "cursor", Value: p.Cursor}
+		}
+
+		value, ok := cursorValue(field.FieldType, p.after.Values[i])
+		if !ok {
+			return &QueryError{Parameter: "cursor", Value: p.Cursor}
+		}
+		p.after.Values[i] = value
+	}
+
+	return nil
+}
+
+// cursorValue reads a decoded cursor value as the given type, times were written as RFC 3339 strings
+func cursorValue(fieldType reflect.Type, value interface{}) (interface{}, bool) {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if fieldType == reflect.TypeOf(time.Time{}) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		s, ok := value.(string)
+		return s, ok
+	case reflect.Bool:
+		b, ok := value.(bool)
+		return b, ok
+	}
+
+	number, ok := value.(json.Number)
+	if !ok {
+		return nil, false
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(number.String(), 10, fieldType.Bits())
+		return n, err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(number.String(), 10, fieldType.Bits())
+		return n, err == nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(number.String(), fieldType.Bits())
+		return n, err == nil
+	}
+
+	return nil, false
+}
+
+func (p *Pagination) cursorOf(modelSchema *schema.Schema, row reflect.Value, direction string) string {
+	keyset := p.keyset()
+	values := make([]interface{}, len(keyset))
+	for i, order := range keyset {
+		if field := modelSchema.LookUpField(order.Column.Name); field != nil {
+			values[i], _ = field.ValueOf(context.Background(), reflect.Indirect(row))
+		}
+	}
+
+	return encodeCursor(cursor{Sort: p.Sort, Direction: direction, Values: values})
+}
+
+// parseSchema reads the schema of the model, the cursor values are read from its fields
+func parseSchema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	return stmt.Schema, nil
+}
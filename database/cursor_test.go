@@ -0,0 +1,84 @@
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+type cursorRow struct {
+	ID        uint
+	Name      string
+	CreatedAt time.Time
+}
+
+var cursorAllowlist = QueryAllowlist{Sortable: []string{"id", "name", "created_at"}}
+
+func rawCursor(json string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(json))
+}
+
+func typedCursor(t *testing.T, sort, encoded string) error {
+	t.Helper()
+
+	p := &Pagination{CursorMode: true, Sort: sort, Cursor: encoded}
+	if err := p.Validate(cursorAllowlist); err != nil {
+		return err
+	}
+
+	rowSchema, err := schema.Parse(&cursorRow{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p.typeCursor(rowSchema)
+}
+
+func TestCursorRejectsMalformedValues(t *testing.T) {
+	cursors := []struct{ name, sort, raw string }{
+		{"object", "name", `{"s":"name","d":"next","v":[{"a":1},7]}`},
+		{"array", "name", `{"s":"name","d":"next","v":[["a"],7]}`},
+		{"null", "name", `{"s":"name","d":"next","v":[null,7]}`},
+		{"string id", "name", `{"s":"name","d":"next","v":["ada","7"]}`},
+		{"negative id", "name", `{"s":"name","d":"next","v":["ada",-7]}`},
+		{"fractional id", "name", `{"s":"name","d":"next","v":["ada",7.5]}`},
+		{"number name", "name", `{"s":"name","d":"next","v":[1,7]}`},
+		{"bad time", "-created_at", `{"s":"-created_at","d":"next","v":["yesterday",7]}`},
+	}
+
+	for _, c := range cursors {
+		err := typedCursor(t, c.sort, rawCursor(c.raw))
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) || queryErr.Parameter != "cursor" {
+			t.Errorf("%s: err = %v, want a cursor QueryError", c.name, err)
+		}
+	}
+}
+
+func TestCursorTypesValues(t *testing.T) {
+	created := time.Date(2024, 6, 1, 12, 30, 0, 500, time.UTC)
+	encoded := encodeCursor(cursor{Sort: "-created_at", Direction: cursorNext, Values: []interface{}{created, uint(7)}})
+
+	p := &Pagination{CursorMode: true, Sort: "-created_at", Cursor: encoded}
+	if err := p.Validate(cursorAllowlist); err != nil {
+		t.Fatal(err)
+	}
+	rowSchema, err := schema.Parse(&cursorRow{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.typeCursor(rowSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	if at, ok := p.after.Values[0].(time.Time); !ok || !at.Equal(created) {
+		t.Errorf("created_at = %#v, want %v", p.after.Values[0], created)
+	}
+	if id, ok := p.after.Values[1].(uint64); !ok || id != 7 {
+		t.Errorf("id = %#v, want 7", p.after.Values[1])
+	}
+}
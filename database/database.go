@@ -1,34 +1,53 @@
 package database
 
 import (
-	"math"
-
-	"gorm.io/gorm"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"gorm.io/gorm/clause"
 )
 
+// Pagination is the page a list request asks for, see Paginate
 type Pagination struct {
-	Limit      int         `json:"limit" query:"limit"`
-	Page       int         `json:"page" query:"page"`
-	Sort       string      `json:"sort,omitempty" query:"sort"`
-	TotalRows  int64       `json:"total_rows"`
-	TotalPages int         `json:"total_pages"`
-	Data       interface{} `json:"data"`
+	Limit int `json:"limit" query:"limit"`
+	Page  int `json:"page" query:"page"`
+	// Sort lists columns, descending with a leading "-", e.g. "-created_at,last_name"
+	Sort string `json:"sort,omitempty" query:"sort"`
+	// Filters are the filter[column][operator] query parameters, see ParseFilters
+	Filters []Filter `json:"filters,omitempty"`
+	// Fields selects the fields of the rows, all fields when empty
+	Fields []string `json:"fields,omitempty"`
+	// CursorMode pages by the sort columns after Cursor instead of by offset,
+	// without counting the rows unless Count asks for it
+	CursorMode bool   `json:"-"`
+	Cursor     string `json:"-" query:"cursor"`
+	Count      string `json:"-" query:"count"`
+
+	// Set by Validate
+	order    []clause.OrderByColumn
+	where    []clause.Expression
+	selected []string
+	after    *cursor
+	// Set by RankBy
+	rank clause.Expression
 }
 
 func (p *Pagination) GetOffset() int {
 	return (p.GetPage() - 1) * p.GetLimit()
 }
 
+// GetLimit defaults to 10 rows and caps the limit at the maximum page size
 func (p *Pagination) GetLimit() int {
-	if p.Limit == 0 {
+	if p.Limit < 1 {
 		p.Limit = 10
 	}
+	if maxLimit := config.AppConfig.PaginationMaxLimit; maxLimit > 0 && p.Limit > maxLimit {
+		p.Limit = maxLimit
+	}
 
 	return p.Limit
 }
 
 func (p *Pagination) GetPage() int {
-	if p.Page == 0 {
+	if p.Page < 1 {
 		p.Page = 1
 	}
 
@@ -37,31 +56,18 @@ func (p *Pagination) GetPage() int {
 
 func (p *Pagination) GetSort() string {
 	if p.Sort == "" {
-		p.Sort = "id desc"
+		p.Sort = "-id"
 	}
 
 	return p.Sort
 }
 
 func (p *Pagination) SetSort(sort string) string {
-	if p.Sort != "" {
+	if sort != "" {
 		p.Sort = sort
 	} else {
-		p.Sort = "id desc"
+		p.Sort = "-id"
 	}
 
 	return p.Sort
 }
-
-func Paginate(value interface{}, pagination *Pagination, db *gorm.DB) func(db *gorm.DB) *gorm.DB {
-	var totalRows int64
-	db.Model(value).Count(&totalRows)
-
-	pagination.TotalRows = totalRows
-	totalPages := int(math.Ceil(float64(totalRows) / float64(pagination.Limit)))
-	pagination.TotalPages = totalPages
-
-	return func(db *gorm.DB) *gorm.DB {
-		return db.Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).Order(pagination.GetSort())
-	}
-}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils/color"
+	"github.com/getsentry/sentry-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// MigrationsPath is the directory of the SQL migrations in the source tree,
+// new migrations are named by the time they were created
+const MigrationsPath = "database/migrations"
+
+// The migrations are embedded so the binary migrates from any directory
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Migration is a migration file and whether the database has applied it
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// migrationSource opens the embedded migrations, or the MIGRATIONS_PATH
+// directory which overrides them for hotfixes
+func migrationSource() (source.Driver, error) {
+	if path := config.AppConfig.MigrationsPath; path != "" {
+		return (&file.File{}).Open("file://" + path)
+	}
+
+	return iofs.New(migrationsFS, "migrations")
+}
+
+func newMigrator() (*migrate.Migrate, error) {
+	sourceDriver, err := migrationSource()
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithSourceInstance("migrations", sourceDriver, config.AppConfig.DatabaseURL)
+}
+
+// runMigrator runs a migration command and closes the migrator, the database
+// being up to date already is not an error
+func runMigrator(run func(migrator *migrate.Migrate) error) error {
+	migrator, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err = run(migrator); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		sentry.CaptureException(err)
+		return err
+	}
+
+	return nil
+}
+
+func Migrate() {
+	// Run the migrations
+	log.Println("Migrating the schema...")
+	if err := runMigrator((*migrate.Migrate).Up); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Migration completed, Close the database connection...")
+}
+
+func Rollback() {
+	// Rollback every migration
+	log.Println("Rollback the schema...")
+	if err := runMigrator((*migrate.Migrate).Down); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Rollback completed, Close the database connection...")
+}
+
+// MigrateSteps applies the next n migrations, or rolls back the last n when n is negative
+func MigrateSteps(n int) error {
+	return runMigrator(func(migrator *migrate.Migrate) error {
+		return migrator.Steps(n)
+	})
+}
+
+// MigrateTo migrates up or down to the version
+func MigrateTo(version uint) error {
+	return runMigrator(func(migrator *migrate.Migrate) error {
+		return migrator.Migrate(version)
+	})
+}
+
+// ForceVersion sets the version without running migrations and clears the
+// dirty flag of a failed migration, -1 means no migration is applied
+func ForceVersion(version int) error {
+	return runMigrator(func(migrator *migrate.Migrate) error {
+		return migrator.Force(version)
+	})
+}
+
+// MigrationVersion returns the applied version, 0 when no migration is applied
+func MigrationVersion() (version uint, dirty bool, err error) {
+	err = runMigrator(func(migrator *migrate.Migrate) error {
+		version, dirty, err = migrator.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return nil
+		}
+		return err
+	})
+
+	return version, dirty, err
+}
+
+// MigrationStatus lists the migration files with the applied version
+func MigrationStatus() (migrations []Migration, version uint, dirty bool, err error) {
+	if version, dirty, err = MigrationVersion(); err != nil {
+		return nil, 0, false, err
+	}
+	if migrations, err = listMigrations(); err != nil {
+		return nil, 0, false, err
+	}
+	for i := range migrations {
+		migrations[i].Applied = migrations[i].Version <= version
+	}
+
+	return migrations, version, dirty, nil
+}
+
+// listMigrations lists the migrations of the source in version order
+func listMigrations() (migrations []Migration, err error) {
+	sourceDriver, err := migrationSource()
+	if err != nil {
+		return nil, err
+	}
+	defer sourceDriver.Close()
+
+	next, err := sourceDriver.First()
+	for err == nil {
+		reader, identifier, readErr := sourceDriver.ReadUp(next)
+		if readErr != nil && !errors.Is(readErr, os.ErrNotExist) {
+			return nil, readErr
+		}
+		if reader != nil {
+			reader.Close()
+		}
+
+		migrations = append(migrations, Migration{Version: next, Name: identifier})
+		next, err = sourceDriver.Next(next)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return migrations, nil
+}
+
+// CheckMigrationVersion compares the schema version of the database with the
+// latest migration of the binary. A mismatch is logged, or stops the service
+// when MIGRATIONS_STRICT is set.
+func CheckMigrationVersion() {
+	if fiber.IsChild() {
+		return
+	}
+
+	migrations, err := listMigrations()
+	if err != nil {
+		log.Fatal("MigrationError:", err)
+	}
+	var expected uint
+	if len(migrations) > 0 {
+		expected = migrations[len(migrations)-1].Version
+	}
+
+	version, dirty, err := MigrationVersion()
+	if err != nil {
+		log.Fatal("MigrationError:", err)
+	}
+	if version == expected && !dirty {
+		return
+	}
+
+	message := fmt.Sprintf("Database schema version is %d, the service expects %d", version, expected)
+	if dirty {
+		message = fmt.Sprintf("Database schema version %d is dirty, the service expects %d", version, expected)
+	}
+	if config.AppConfig.MigrationsStrict {
+		log.Fatal(message)
+	}
+	log.Println(color.Format(color.YELLOW, message))
+}
+
+// CreateMigration writes empty up and down files named by the current time
+// into the source tree, or the MIGRATIONS_PATH directory
+func CreateMigration(name string) (up string, down string, err error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !migrationNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("migration name %q must be snake_case", name)
+	}
+
+	path := MigrationsPath
+	if config.AppConfig.MigrationsPath != "" {
+		path = config.AppConfig.MigrationsPath
+	}
+
+	base := filepath.Join(path, time.Now().UTC().Format("20060102150405")+"_"+name)
+	up, down = base+".up.sql", base+".down.sql"
+	for _, migrationFile := range []string{up, down} {
+		if err = os.WriteFile(migrationFile, nil, 0o644); err != nil {
+			return "", "", err
+		}
+	}
+
+	return up, down, nil
+}
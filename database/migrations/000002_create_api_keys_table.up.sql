@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR (100) NOT NULL,
+  prefix VARCHAR (16) UNIQUE NOT NULL,
+  key_hash VARCHAR (64) NOT NULL,
+  scopes TEXT[] NOT NULL DEFAULT '{}',
+  rate_limit INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NULL,
+  last_used_at TIMESTAMP NULL,
+  revoked_at TIMESTAMP NULL,
+  rotated_from_id BIGINT NULL REFERENCES api_keys (id),
+  created_at TIMESTAMP NULL,
+  updated_at TIMESTAMP NULL,
+  deleted_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+-- comments
+COMMENT ON COLUMN api_keys.id IS 'The API key ID';
+COMMENT ON COLUMN api_keys.name IS 'The API key display name, e.g. the kiosk or partner';
+COMMENT ON COLUMN api_keys.prefix IS 'The public lookup prefix of the key';
+COMMENT ON COLUMN api_keys.key_hash IS 'SHA-256 hash of the secret part of the key';
+COMMENT ON COLUMN api_keys.scopes IS 'The scopes granted to the key';
+COMMENT ON COLUMN api_keys.rate_limit IS 'Requests per minute, 0 means the default limit';
+COMMENT ON COLUMN api_keys.expires_at IS 'Expire time';
+COMMENT ON COLUMN api_keys.last_used_at IS 'Last time the key authenticated a request';
+COMMENT ON COLUMN api_keys.revoked_at IS 'Revoke time';
+COMMENT ON COLUMN api_keys.rotated_from_id IS 'The key this key replaced by rotation';
+COMMENT ON COLUMN api_keys.created_at IS 'Create time';
+COMMENT ON COLUMN api_keys.updated_at IS 'Update time';
+COMMENT ON COLUMN api_keys.deleted_at IS 'Delete time';
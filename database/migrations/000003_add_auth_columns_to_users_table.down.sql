@@ -0,0 +1,6 @@
+ALTER TABLE users
+  DROP COLUMN IF EXISTS password_hash,
+  DROP COLUMN IF EXISTS role,
+  DROP COLUMN IF EXISTS mfa_enabled,
+  DROP COLUMN IF EXISTS mfa_secret,
+  DROP COLUMN IF EXISTS mfa_enabled_at;
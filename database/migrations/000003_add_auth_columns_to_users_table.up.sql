@@ -0,0 +1,12 @@
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS password_hash VARCHAR (255) NULL,
+  ADD COLUMN IF NOT EXISTS role VARCHAR (30) NOT NULL DEFAULT 'member',
+  ADD COLUMN IF NOT EXISTS mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS mfa_secret VARCHAR (255) NULL,
+  ADD COLUMN IF NOT EXISTS mfa_enabled_at TIMESTAMP NULL;
+-- comments
+COMMENT ON COLUMN users.password_hash IS 'The bcrypt hash of the user password';
+COMMENT ON COLUMN users.role IS 'The user role, e.g. member, staff or admin';
+COMMENT ON COLUMN users.mfa_enabled IS 'Whether TOTP multi-factor authentication is enabled';
+COMMENT ON COLUMN users.mfa_secret IS 'The encrypted TOTP secret';
+COMMENT ON COLUMN users.mfa_enabled_at IS 'MFA enable time';
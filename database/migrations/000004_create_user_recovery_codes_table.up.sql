@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  code_hash VARCHAR (64) NOT NULL,
+  used_at TIMESTAMP NULL,
+  created_at TIMESTAMP NULL,
+  updated_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+-- comments
+COMMENT ON COLUMN user_recovery_codes.id IS 'The recovery code ID';
+COMMENT ON COLUMN user_recovery_codes.user_id IS 'The owner user ID';
+COMMENT ON COLUMN user_recovery_codes.code_hash IS 'SHA-256 hash of the recovery code';
+COMMENT ON COLUMN user_recovery_codes.used_at IS 'Use time, a code can be used once';
+COMMENT ON COLUMN user_recovery_codes.created_at IS 'Create time';
+COMMENT ON COLUMN user_recovery_codes.updated_at IS 'Update time';
@@ -0,0 +1,23 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP NULL;
+COMMENT ON COLUMN users.email_verified_at IS 'Email verify time';
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  purpose VARCHAR (30) NOT NULL,
+  token_hash VARCHAR (64) UNIQUE NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP NULL,
+  created_at TIMESTAMP NULL,
+  updated_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id_purpose ON user_tokens (user_id, purpose);
+-- comments
+COMMENT ON COLUMN user_tokens.id IS 'The token ID';
+COMMENT ON COLUMN user_tokens.user_id IS 'The owner user ID';
+COMMENT ON COLUMN user_tokens.purpose IS 'What the token is for, e.g. email_verification or password_reset';
+COMMENT ON COLUMN user_tokens.token_hash IS 'SHA-256 hash of the signed token';
+COMMENT ON COLUMN user_tokens.expires_at IS 'Expire time';
+COMMENT ON COLUMN user_tokens.used_at IS 'Use time, a token can be used once';
+COMMENT ON COLUMN user_tokens.created_at IS 'Create time';
+COMMENT ON COLUMN user_tokens.updated_at IS 'Update time';
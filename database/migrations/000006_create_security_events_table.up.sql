@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS security_events (
+  id BIGSERIAL PRIMARY KEY,
+  event VARCHAR (50) NOT NULL,
+  user_id BIGINT NULL,
+  email VARCHAR (300) NULL,
+  ip VARCHAR (45) NULL,
+  actor VARCHAR (100) NULL,
+  created_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_security_events_email ON security_events (email);
+CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events (created_at);
+-- comments
+COMMENT ON COLUMN security_events.id IS 'The security event ID';
+COMMENT ON COLUMN security_events.event IS 'What happened, e.g. login_failed or account_locked';
+COMMENT ON COLUMN security_events.user_id IS 'The user ID, NULL when the email is not registered';
+COMMENT ON COLUMN security_events.email IS 'The email used in the attempt';
+COMMENT ON COLUMN security_events.ip IS 'The client IP';
+COMMENT ON COLUMN security_events.actor IS 'The admin who triggered the event';
+COMMENT ON COLUMN security_events.created_at IS 'Create time';
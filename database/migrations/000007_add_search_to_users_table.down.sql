@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_users_search_trgm;
+DROP INDEX IF EXISTS idx_users_search_vector;
+DROP INDEX IF EXISTS idx_users_phone;
+DROP INDEX IF EXISTS idx_users_member_code;
+ALTER TABLE users
+  DROP COLUMN IF EXISTS search_vector,
+  DROP COLUMN IF EXISTS member_code,
+  DROP COLUMN IF EXISTS phone;
+-- pg_trgm is kept, other tables may use it
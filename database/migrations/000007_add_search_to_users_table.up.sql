@@ -0,0 +1,16 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS phone VARCHAR (30) NULL,
+  ADD COLUMN IF NOT EXISTS member_code VARCHAR (30) NULL,
+  ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple'::regconfig, first_name || ' ' || last_name), 'A') ||
+    setweight(to_tsvector('simple'::regconfig, email), 'B')
+  ) STORED;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_member_code ON users (member_code);
+CREATE INDEX IF NOT EXISTS idx_users_phone ON users (phone);
+CREATE INDEX IF NOT EXISTS idx_users_search_vector ON users USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users USING GIN ((first_name || ' ' || last_name || ' ' || email) gin_trgm_ops);
+-- comments
+COMMENT ON COLUMN users.phone IS 'The user phone number, digits only';
+COMMENT ON COLUMN users.member_code IS 'The membership code printed on the member card';
+COMMENT ON COLUMN users.search_vector IS 'Full-text search document of the names and email, the simple configuration does not stem so Thai and English names match alike';
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+  id BIGSERIAL PRIMARY KEY,
+  action VARCHAR (10) NOT NULL,
+  entity VARCHAR (100) NOT NULL,
+  entity_id VARCHAR (100) NOT NULL,
+  actor_type VARCHAR (20) NOT NULL,
+  actor_id VARCHAR (100) NULL,
+  request_id VARCHAR (100) NULL,
+  ip VARCHAR (45) NULL,
+  changes JSONB NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor_type, actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+-- audit logs are append-only
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER audit_logs_no_update_or_delete BEFORE UPDATE OR DELETE ON audit_logs
+  FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+  FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
+-- comments
+COMMENT ON COLUMN audit_logs.id IS 'The audit log ID';
+COMMENT ON COLUMN audit_logs.action IS 'create, update or delete';
+COMMENT ON COLUMN audit_logs.entity IS 'The table of the changed row';
+COMMENT ON COLUMN audit_logs.entity_id IS 'The primary key of the changed row';
+COMMENT ON COLUMN audit_logs.actor_type IS 'The principal type, anonymous or system outside of a request';
+COMMENT ON COLUMN audit_logs.actor_id IS 'The principal subject';
+COMMENT ON COLUMN audit_logs.request_id IS 'The X-Request-ID of the request';
+COMMENT ON COLUMN audit_logs.ip IS 'The client IP';
+COMMENT ON COLUMN audit_logs.changes IS 'The changed columns before and after, secrets are redacted';
+COMMENT ON COLUMN audit_logs.created_at IS 'Create time';
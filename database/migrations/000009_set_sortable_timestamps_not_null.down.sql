@@ -0,0 +1,14 @@
+ALTER TABLE audit_logs
+  ALTER COLUMN created_at DROP NOT NULL,
+  ALTER COLUMN created_at DROP DEFAULT;
+ALTER TABLE security_events
+  ALTER COLUMN created_at DROP NOT NULL,
+  ALTER COLUMN created_at DROP DEFAULT;
+ALTER TABLE api_keys
+  ALTER COLUMN created_at DROP NOT NULL,
+  ALTER COLUMN created_at DROP DEFAULT;
+ALTER TABLE users
+  ALTER COLUMN updated_at DROP NOT NULL,
+  ALTER COLUMN updated_at DROP DEFAULT,
+  ALTER COLUMN created_at DROP NOT NULL,
+  ALTER COLUMN created_at DROP DEFAULT;
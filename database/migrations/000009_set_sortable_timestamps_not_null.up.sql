@@ -0,0 +1,26 @@
+-- sortable columns must be NOT NULL for keyset cursors, rows without a time get the closest known one
+UPDATE users SET created_at = COALESCE(updated_at, NOW()) WHERE created_at IS NULL;
+UPDATE users SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE users
+  ALTER COLUMN created_at SET DEFAULT NOW(),
+  ALTER COLUMN created_at SET NOT NULL,
+  ALTER COLUMN updated_at SET DEFAULT NOW(),
+  ALTER COLUMN updated_at SET NOT NULL;
+
+UPDATE api_keys SET created_at = COALESCE(updated_at, NOW()) WHERE created_at IS NULL;
+ALTER TABLE api_keys
+  ALTER COLUMN created_at SET DEFAULT NOW(),
+  ALTER COLUMN created_at SET NOT NULL;
+
+UPDATE security_events SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE security_events
+  ALTER COLUMN created_at SET DEFAULT NOW(),
+  ALTER COLUMN created_at SET NOT NULL;
+
+-- the backfill is the only change audit_logs allows
+ALTER TABLE audit_logs DISABLE TRIGGER audit_logs_no_update_or_delete;
+UPDATE audit_logs SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE audit_logs ENABLE TRIGGER audit_logs_no_update_or_delete;
+ALTER TABLE audit_logs
+  ALTER COLUMN created_at SET DEFAULT NOW(),
+  ALTER COLUMN created_at SET NOT NULL;
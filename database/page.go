@@ -0,0 +1,111 @@
+package database
+
+import (
+	"encoding/json"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// Page is a page of rows read by Paginate
+type Page[T any] struct {
+	Limit      int      `json:"limit"`
+	Page       int      `json:"page"`
+	Sort       string   `json:"sort,omitempty"`
+	Filters    []Filter `json:"filters,omitempty"`
+	Fields     []string `json:"fields,omitempty"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	PrevCursor string   `json:"prev_cursor,omitempty"`
+	TotalRows  int64    `json:"total_rows"`
+	TotalPages int      `json:"total_pages"`
+	Data       []T      `json:"data"`
+}
+
+// pageJSON is a Page with only the selected fields of its rows
+type pageJSON struct {
+	Limit      int         `json:"limit"`
+	Page       int         `json:"page"`
+	Sort       string      `json:"sort,omitempty"`
+	Filters    []Filter    `json:"filters,omitempty"`
+	Fields     []string    `json:"fields,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+	TotalRows  int64       `json:"total_rows"`
+	TotalPages int         `json:"total_pages"`
+	Data       interface{} `json:"data"`
+}
+
+// Paginate reads a page of the query with the sort, filters and fields of the
+// pagination once it passed Validate. The rows are counted on the same query,
+// so the total includes the conditions the caller added, such as a search.
+func Paginate[T any](query *gorm.DB, pagination *Pagination) (*Page[T], error) {
+	// Share the conditions of the query between the count and the select
+	query = query.Model(new(T)).Session(&gorm.Session{})
+
+	page := &Page[T]{
+		Limit:   pagination.GetLimit(),
+		Page:    pagination.GetPage(),
+		Sort:    pagination.Sort,
+		Filters: pagination.Filters,
+		Fields:  pagination.selected,
+	}
+
+	if pagination.CursorMode {
+		return paginateCursor(query, pagination, page)
+	}
+
+	if err := query.Scopes(pagination.filter).Count(&page.TotalRows).Error; err != nil {
+		return nil, err
+	}
+	page.TotalPages = int(math.Ceil(float64(page.TotalRows) / float64(page.Limit)))
+
+	if err := query.Scopes(pagination.filter, pagination.orderAndSelect).
+		Offset(pagination.GetOffset()).Limit(page.Limit).
+		Find(&page.Data).Error; err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// MarshalJSON leaves out the fields of the rows which were not selected
+func (p Page[T]) MarshalJSON() ([]byte, error) {
+	page := pageJSON{
+		Limit:      p.Limit,
+		Page:       p.Page,
+		Sort:       p.Sort,
+		Filters:    p.Filters,
+		Fields:     p.Fields,
+		NextCursor: p.NextCursor,
+		PrevCursor: p.PrevCursor,
+		TotalRows:  p.TotalRows,
+		TotalPages: p.TotalPages,
+		Data:       p.Data,
+	}
+	if len(p.Fields) == 0 {
+		return json.Marshal(page)
+	}
+
+	raw, err := json.Marshal(p.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]json.RawMessage
+	if err = json.Unmarshal(raw, &records); err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		selected := make(map[string]json.RawMessage, len(p.Fields))
+		for _, field := range p.Fields {
+			if value, ok := record[field]; ok {
+				selected[field] = value
+			}
+		}
+		records[i] = selected
+	}
+	page.Data = records
+
+	return json.Marshal(page)
+}
@@ -6,11 +6,7 @@ import (
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils/color"
-	"github.com/getsentry/sentry-go"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/plugin/dbresolver"
@@ -29,11 +25,27 @@ func Initialize() (DBConn *gorm.DB) {
 		),
 		&gorm.Config{},
 	)
-	DBConn.Use(
+	if err != nil {
+		log.Printf("Cannot connect to database")
+		log.Fatal("DatabaseError:", err)
+	}
+
+	// Reads go to the replicas, writes and transactions stay on the primary
+	replicas := make([]gorm.Dialector, 0, len(config.AppConfig.DatabaseReplicaDSNs))
+	for _, dsn := range config.AppConfig.DatabaseReplicaDSNs {
+		replicas = append(replicas, postgres.Open(dsn))
+	}
+
+	var policy dbresolver.Policy = dbresolver.RandomPolicy{}
+	if config.AppConfig.DatabaseReplicaPolicy == "latency" {
+		policy = newLatencyPolicy(10 * time.Second)
+	}
+
+	err = DBConn.Use(
 		dbresolver.Register(dbresolver.Config{
 			Sources:           []gorm.Dialector{},
-			Replicas:          []gorm.Dialector{},
-			Policy:            nil,
+			Replicas:          replicas,
+			Policy:            policy,
 			TraceResolverMode: false,
 		}).
 			SetConnMaxIdleTime(time.Hour).
@@ -41,9 +53,18 @@ func Initialize() (DBConn *gorm.DB) {
 			SetMaxIdleConns(config.AppConfig.DatabaseMaxIdleConns).
 			SetMaxOpenConns(config.AppConfig.DatabaseMaxOpenConns),
 	)
+	if err == nil {
+		err = registerConsistency(DBConn)
+	}
+	if err == nil {
+		err = registerUnitOfWork(DBConn)
+	}
+	if err == nil {
+		err = registerAudit(DBConn)
+	}
 
 	if err != nil {
-		log.Printf("Cannot connect to database")
+		log.Printf("Cannot connect to database replicas")
 		log.Fatal("DatabaseError:", err)
 	}
 
@@ -53,53 +74,3 @@ func Initialize() (DBConn *gorm.DB) {
 
 	return
 }
-
-func Migrate() {
-	// Migrate the schema
-	migrator, err := migrate.New(
-		"file://database/migrations",
-		config.AppConfig.DatabaseURL)
-
-	// Check error when create new migrate instance
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Fatal(err)
-	}
-
-	// Run the migrations
-	log.Println("Migrating the schema...")
-	if err := migrator.Up(); err != nil {
-		sentry.CaptureException(err)
-		log.Println(err)
-	}
-
-	log.Println("Migration completed, Close the database connection...")
-
-	// Close the database connection
-	migrator.Close()
-}
-
-func Rollback() {
-	// Migrate the schema
-	migrator, err := migrate.New(
-		"file://database/migrations",
-		config.AppConfig.DatabaseURL)
-
-	// Check error when create new migrate instance
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Fatal(err)
-	}
-
-	// Run the migrations
-	log.Println("Rollback the schema...")
-	if err := migrator.Down(); err != nil {
-		sentry.CaptureException(err)
-		log.Println(err)
-	}
-
-	log.Println("Rollback completed, Close the database connection...")
-
-	// Close the database connection
-	migrator.Close()
-}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Filter operators of the filter[column][operator]=value query parameters
+const (
+	OperatorEq   = "eq"
+	OperatorNe   = "ne"
+	OperatorGt   = "gt"
+	OperatorGte  = "gte"
+	OperatorLt   = "lt"
+	OperatorLte  = "lte"
+	OperatorLike = "like"
+	// OperatorIn takes a comma separated list
+	OperatorIn = "in"
+	// OperatorNull takes true or false
+	OperatorNull = "null"
+)
+
+// filterParam matches filter[column] and filter[column][operator]
+var filterParam = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// Filter is a condition on a column, Column and Operator are only trusted
+// after Pagination.Validate checked them against the allowlist
+type Filter struct {
+	Column   string `json:"column"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// QueryAllowlist lists the columns of a model clients may sort, filter and select
+type QueryAllowlist struct {
+	// Sortable columns must be NOT NULL, cursor pagination cannot seek past NULLs
+	Sortable []string
+	// Filterable maps each column to its operators
+	Filterable map[string][]string
+	Selectable []string
+}
+
+// QueryError is a sort, filter or fields parameter outside the allowlist
+type QueryError struct {
+	Parameter string
+	Value     string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid %s parameter: %s", e.Parameter, e.Value)
+}
+
+// ParseFilters reads the filter[column][operator] query parameters, the
+// operator defaults to eq. Filters are sorted to give a stable cache key.
+func ParseFilters(queries map[string]string) []Filter {
+	var filters []Filter
+	for param, value := range queries {
+		match := filterParam.FindStringSubmatch(param)
+		if match == nil {
+			continue
+		}
+
+		operator := match[2]
+		if operator == "" {
+			operator = OperatorEq
+		}
+		filters = append(filters, Filter{Column: match[1], Operator: operator, Value: value})
+	}
+
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].Column != filters[j].Column {
+			return filters[i].Column < filters[j].Column
+		}
+		return filters[i].Operator < filters[j].Operator
+	})
+
+	return filters
+}
+
+// ParseFields reads the comma separated fields query parameter
+func ParseFields(fields string) []string {
+	var parsed []string
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			parsed = append(parsed, field)
+		}
+	}
+
+	return parsed
+}
+
+// Validate checks the sort, filters and fields against the allowlist. Paginate
+// applies them only once they are validated, so client input never reaches
+// the SQL as is.
+func (p *Pagination) Validate(allowlist QueryAllowlist) error {
+	p.order = nil
+	for _, column := range ParseFields(p.Sort) {
+		desc := strings.HasPrefix(column, "-")
+		column = strings.TrimPrefix(column, "-")
+		if !slices.Contains(allowlist.Sortable, column) {
+			return &QueryError{Parameter: "sort", Value: column}
+		}
+		p.order = append(p.order, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+
+	p.where = nil
+	for _, filter := range p.Filters {
+		if !slices.Contains(allowlist.Filterable[filter.Column], filter.Operator) {
+			return &QueryError{Parameter: "filter", Value: filter.Column + "[" + filter.Operator + "]"}
+		}
+
+		condition, err := filterCondition(filter)
+		if err != nil {
+			return err
+		}
+		p.where = append(p.where, condition)
+	}
+
+	for _, field := range p.Fields {
+		if !slices.Contains(allowlist.Selectable, field) {
+			return &QueryError{Parameter: "fields", Value: field}
+		}
+	}
+	p.selected = p.Fields
+
+	if p.CursorMode {
+		return p.validateCursor()
+	}
+
+	return nil
+}
+
+func filterCondition(filter Filter) (clause.Expression, error) {
+	column := clause.Column{Name: filter.Column}
+
+	switch filter.Operator {
+	case OperatorEq:
+		return clause.Eq{Column: column, Value: filter.Value}, nil
+	case OperatorNe:
+		return clause.Neq{Column: column, Value: filter.Value}, nil
+	case OperatorGt:
+		return clause.Gt{Column: column, Value: filter.Value}, nil
+	case OperatorGte:
+		return clause.Gte{Column: column, Value: filter.Value}, nil
+	case OperatorLt:
+		return clause.Lt{Column: column, Value: filter.Value}, nil
+	case OperatorLte:
+		return clause.Lte{Column: column, Value: filter.Value}, nil
+	case OperatorLike:
+		return clause.Like{Column: column, Value: ContainsPattern(filter.Value)}, nil
+	case OperatorIn:
+		values := make([]interface{}, 0)
+		for _, value := range ParseFields(filter.Value) {
+			values = append(values, value)
+		}
+		return clause.IN{Column: column, Values: values}, nil
+	case OperatorNull:
+		switch filter.Value {
+		case "true":
+			return clause.Eq{Column: column, Value: nil}, nil
+		case "false":
+			return clause.Neq{Column: column, Value: nil}, nil
+		}
+	}
+
+	return nil, &QueryError{Parameter: "filter", Value: filter.Column + "[" + filter.Operator + "]=" + filter.Value}
+}
+
+// ContainsPattern is a LIKE pattern matching the value anywhere, wildcards in
+// the value are matched literally
+func ContainsPattern(value string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value) + "%"
+}
+
+// filter applies the validated filters
+func (p *Pagination) filter(db *gorm.DB) *gorm.DB {
+	for _, condition := range p.where {
+		db = db.Where(condition)
+	}
+
+	return db
+}
+
+// RankBy orders the rows by the expression, e.g. search relevance, unless the
+// client asked for a sort. Cursor pagination seeks by the sort columns only
+// and ignores it.
+func (p *Pagination) RankBy(rank clause.Expression) {
+	p.rank = rank
+}
+
+// orderAndSelect applies the validated sort, by rank and id descending by default, and field selection
+func (p *Pagination) orderAndSelect(db *gorm.DB) *gorm.DB {
+	if len(p.order) == 0 && p.rank != nil {
+		db = db.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "?, ? DESC",
+			Vars: []interface{}{p.rank, clause.Column{Name: "id"}},
+		}})
+	} else if len(p.order) == 0 {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: true})
+	}
+	for _, order := range p.order {
+		db = db.Order(order)
+	}
+
+	if len(p.selected) > 0 {
+		db = db.Select(p.selected)
+	}
+
+	return db
+}
+
+// QueryKey identifies the sort, filters and fields of the page, e.g. in cache keys
+func (p *Pagination) QueryKey() string {
+	filters := make([]string, len(p.Filters))
+	for i, filter := range p.Filters {
+		filters[i] = filter.Column + "[" + filter.Operator + "]=" + filter.Value
+	}
+
+	key := strings.Join([]string{p.Sort, strings.Join(filters, "&"), strings.Join(p.Fields, ",")}, "|")
+	if p.CursorMode {
+		key += "|cursor:" + p.Cursor + "|count:" + p.Count
+	}
+
+	return key
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// consistencyKey is the context key of the Consistency of a request
+type consistencyKey struct{}
+
+// ConsistencyKey stores the Consistency of a request in the fiber locals or a
+// context, which the repositories pass to their queries
+var ConsistencyKey = consistencyKey{}
+
+// Consistency is the read-your-writes state of a request. Reads go to the
+// replicas unless Primary is set, and Wrote reports whether the request
+// changed any rows so that the caller reads from the primary for a while.
+type Consistency struct {
+	Primary bool
+	wrote   atomic.Bool
+}
+
+func (c *Consistency) Wrote() bool {
+	return c.wrote.Load()
+}
+
+// WithConsistency returns a context which queries use to route by consistency
+func WithConsistency(ctx context.Context, consistency *Consistency) context.Context {
+	return context.WithValue(ctx, ConsistencyKey, consistency)
+}
+
+func consistencyOf(db *gorm.DB) *Consistency {
+	if db.Statement.Context == nil {
+		return nil
+	}
+	consistency, _ := db.Statement.Context.Value(ConsistencyKey).(*Consistency)
+
+	return consistency
+}
+
+// registerConsistency pins the reads of a request to the primary after it
+// wrote, by resolving the connection of a statement again after dbresolver
+func registerConsistency(db *gorm.DB) error {
+	primary := func(db *gorm.DB) {
+		if consistency := consistencyOf(db); consistency != nil && consistency.Primary {
+			dbresolver.Write.ModifyStatement(db.Statement)
+		}
+	}
+	wrote := func(db *gorm.DB) {
+		if consistency := consistencyOf(db); consistency != nil && db.Error == nil && db.RowsAffected > 0 {
+			consistency.wrote.Store(true)
+		}
+	}
+	wroteRaw := func(db *gorm.DB) {
+		if sql := strings.TrimSpace(db.Statement.SQL.String()); len(sql) < 6 || !strings.EqualFold(sql[:6], "select") {
+			wrote(db)
+		}
+	}
+
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Query().After("gorm:db_resolver").Register("database:primary", primary),
+		callback.Row().After("gorm:db_resolver").Register("database:primary", primary),
+		callback.Raw().After("gorm:db_resolver").Register("database:primary", primary),
+		callback.Create().After("gorm:create").Register("database:wrote", wrote),
+		callback.Update().After("gorm:update").Register("database:wrote", wrote),
+		callback.Delete().After("gorm:delete").Register("database:wrote", wrote),
+		callback.Raw().After("gorm:raw").Register("database:wrote", wroteRaw),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// latencyPolicy sends reads to the replica which answered the last ping the
+// fastest, the replicas are pinged again in the background every interval
+// and picked at random until the first pings return
+type latencyPolicy struct {
+	interval  time.Duration
+	mu        sync.Mutex
+	checkedAt time.Time
+	latencies map[gorm.ConnPool]time.Duration
+}
+
+func newLatencyPolicy(interval time.Duration) *latencyPolicy {
+	return &latencyPolicy{interval: interval, latencies: map[gorm.ConnPool]time.Duration{}}
+}
+
+func (p *latencyPolicy) Resolve(connPools []gorm.ConnPool) gorm.ConnPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.checkedAt) > p.interval {
+		p.checkedAt = time.Now()
+		go p.ping(connPools)
+	}
+
+	fastest := connPools[rand.Intn(len(connPools))]
+	fastestLatency, measured := p.latencies[fastest]
+	for _, connPool := range connPools {
+		if latency, ok := p.latencies[connPool]; ok && (!measured || latency < fastestLatency) {
+			fastest, fastestLatency, measured = connPool, latency, true
+		}
+	}
+
+	return fastest
+}
+
+func (p *latencyPolicy) ping(connPools []gorm.ConnPool) {
+	latencies := make(map[gorm.ConnPool]time.Duration, len(connPools))
+	for _, connPool := range connPools {
+		pinger, ok := connPool.(interface{ PingContext(context.Context) error })
+		if !ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+		start := time.Now()
+		// An unreachable replica is only picked when every replica is down
+		latency := p.interval
+		if err := pinger.PingContext(ctx); err == nil {
+			latency = time.Since(start)
+		}
+		cancel()
+		latencies[connPool] = latency
+	}
+
+	p.mu.Lock()
+	p.latencies = latencies
+	p.mu.Unlock()
+}
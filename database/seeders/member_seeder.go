@@ -0,0 +1,103 @@
+package seeders
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/brianvoe/gofakeit/v6"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	memberSeedCount = 200
+	// Fake members are told apart from real ones by their member code
+	memberSeedCodePrefix = "DEV"
+)
+
+// Thai names mixed into the fake members, so search is tried with Thai text
+var (
+	thaiFirstNames = []string{"สมชาย", "สมหญิง", "ประเสริฐ", "วิภาวดี", "ณัฐพล", "กนกวรรณ", "ธนากร", "พิมพ์ชนก", "อนุชา", "ศิริพร"}
+	thaiLastNames  = []string{"ใจดี", "รักไทย", "ศรีสุข", "วงศ์สวัสดิ์", "บุญมา", "แก้วมณี", "สุขสวัสดิ์", "ทองดี", "มีสุข", "ชัยมงคล"}
+)
+
+type memberSeeder struct {
+	db *gorm.DB
+}
+
+func NewMemberSeeder(db *gorm.DB) Seeder {
+	return memberSeeder{db: db}
+}
+
+// Implement seed method
+func (s memberSeeder) Seed() error {
+	log.Println("MemberSeeder running...")
+
+	members := generateMembers(gofakeit.New(fakeSeed))
+
+	// Upsert by member code, so seeding again keeps the ids of the members
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "member_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"first_name", "last_name", "email", "phone", "role", "email_verified_at", "updated_at", "deleted_at"}),
+	}).CreateInBatches(&members, 100)
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Println("MemberSeeder seeded!")
+
+	return nil
+}
+
+// Implement clear method
+func (s memberSeeder) Clear() error {
+	log.Println("Clear MemberSeeder...")
+	result := s.db.Unscoped().Where(`member_code LIKE ?`, memberSeedCodePrefix+"%").Delete(&models.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Println("MemberSeeder cleared!")
+
+	return nil
+}
+
+// generateMembers generates the same members for the same faker seed
+func generateMembers(faker *gofakeit.Faker) []models.User {
+	members := make([]models.User, 0, memberSeedCount)
+	for i := 1; i <= memberSeedCount; i++ {
+		firstName, lastName := faker.FirstName(), faker.LastName()
+		emailName := strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || r == '.' {
+				return r
+			}
+			return -1
+		}, strings.ToLower(firstName+"."+lastName))
+		// A third of the members have Thai names
+		if faker.Number(1, 3) == 1 {
+			firstName = thaiFirstNames[faker.Number(0, len(thaiFirstNames)-1)]
+			lastName = thaiLastNames[faker.Number(0, len(thaiLastNames)-1)]
+			emailName = "member"
+		}
+
+		code := fmt.Sprintf("%s%06d", memberSeedCodePrefix, i)
+		phone := fmt.Sprintf("0%d%08d", faker.Number(6, 9), faker.Number(0, 99999999))
+		member := models.User{
+			FirstName:  firstName,
+			LastName:   lastName,
+			Email:      fmt.Sprintf("%s.%d@example.com", emailName, i),
+			Phone:      &phone,
+			MemberCode: &code,
+			Role:       models.RoleMember,
+		}
+		// Most members verified their email within the year before the anchor
+		if faker.Number(1, 10) > 2 {
+			verifiedAt := faker.DateRange(fakeAnchor.AddDate(-1, 0, 0), fakeAnchor)
+			member.EmailVerifiedAt = &verifiedAt
+		}
+
+		members = append(members, member)
+	}
+
+	return members
+}
@@ -0,0 +1,85 @@
+package seeders
+
+import (
+	"log"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/brianvoe/gofakeit/v6"
+	"gorm.io/gorm"
+)
+
+// securityEventSeedActor marks the seeded events, so Clear leaves the real ones
+const securityEventSeedActor = "seeder"
+
+type securityEventSeeder struct {
+	db *gorm.DB
+}
+
+func NewSecurityEventSeeder(db *gorm.DB) Seeder {
+	return securityEventSeeder{db: db}
+}
+
+// Implement seed method, the events of the last run are replaced as they have no natural key
+func (s securityEventSeeder) Seed() error {
+	log.Println("SecurityEventSeeder running...")
+
+	var members []models.User
+	if err := s.db.Where(`member_code LIKE ?`, memberSeedCodePrefix+"%").Order("member_code").Find(&members).Error; err != nil {
+		return err
+	}
+
+	events := generateSecurityEvents(gofakeit.New(fakeSeed), members)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(`actor = ?`, securityEventSeedActor).Delete(&models.SecurityEvent{}).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&events, 100).Error
+	})
+	if err != nil {
+		return err
+	}
+	log.Println("SecurityEventSeeder seeded!")
+
+	return nil
+}
+
+// Implement clear method
+func (s securityEventSeeder) Clear() error {
+	log.Println("Clear SecurityEventSeeder...")
+	result := s.db.Where(`actor = ?`, securityEventSeedActor).Delete(&models.SecurityEvent{})
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Println("SecurityEventSeeder cleared!")
+
+	return nil
+}
+
+// generateSecurityEvents generates the logins of the 30 days before the anchor, with a few failed attempts
+func generateSecurityEvents(faker *gofakeit.Faker, members []models.User) []models.SecurityEvent {
+	events := make([]models.SecurityEvent, 0, len(members)*3)
+	for i := range members {
+		member := &members[i]
+		ip := faker.IPv4Address()
+		for n := faker.Number(0, 5); n > 0; n-- {
+			event := models.SecurityEventLoginSucceeded
+			if faker.Number(1, 10) == 1 {
+				event = models.SecurityEventLoginFailed
+			}
+
+			events = append(events, models.SecurityEvent{
+				Event:     event,
+				UserID:    &member.ID,
+				Email:     member.Email,
+				IP:        ip,
+				Actor:     securityEventSeedActor,
+				CreatedAt: faker.DateRange(fakeAnchor.AddDate(0, 0, -30), fakeAnchor),
+			})
+		}
+	}
+
+	return events
+}
@@ -1,34 +1,139 @@
 package seeders
 
 import (
+	"fmt"
 	"log"
+	"slices"
+	"time"
 
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
 	"gorm.io/gorm"
 )
 
-type seeder struct {
-	userSeeder UserSeeder
+// fakeSeed seeds the fake data generators, so every run generates the same rows
+const fakeSeed int64 = 20240601
+
+// fakeAnchor is the time fake dates are generated back from, so they don't
+// depend on when the seeders run
+var fakeAnchor = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+type (
+	// Seeder fills tables with data. Seeding again updates the rows of the
+	// last run, and Clear only deletes the rows the seeder owns.
+	Seeder interface {
+		Seed() error
+		Clear() error
+	}
+	// registration describes a seeder and when it runs
+	registration struct {
+		name      string
+		dependsOn []string
+		// environments the seeder runs in, every environment when empty
+		environments []string
+		new          func(db *gorm.DB) Seeder
+	}
+)
+
+var registry = []registration{
+	{name: "users", new: NewUserSeeder},
+	// Members come after the users so the administrator keeps the first id
+	{name: "members", dependsOn: []string{"users"}, environments: []string{"local", "development", "staging"}, new: NewMemberSeeder},
+	{name: "security-events", dependsOn: []string{"members"}, environments: []string{"local", "development"}, new: NewSecurityEventSeeder},
 }
 
-func NewSeeder(
-	db *gorm.DB,
-) seeder {
-	userSeeder := NewUserSeeder(db)
-	return seeder{
-		userSeeder: userSeeder,
+// RunSeed runs the named seeders after their dependencies, or every seeder
+// of the environment when no name is given
+func RunSeed(names []string) {
+	seeders, err := resolve(names)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	database.DBConn = database.Initialize()
+	for _, registration := range seeders {
+		if err = registration.new(database.DBConn).Seed(); err != nil {
+			log.Fatal(err)
+		}
 	}
 }
 
-func RunSeed() {
-	var err error
+// ResetSeed clears the named seeders and their dependencies in reverse order
+// and seeds them again
+func ResetSeed(names []string) {
+	seeders, err := resolve(names)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	database.DBConn = database.Initialize()
+	for i := len(seeders) - 1; i >= 0; i-- {
+		if err = seeders[i].new(database.DBConn).Clear(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, registration := range seeders {
+		if err = registration.new(database.DBConn).Seed(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
 
-	seeder := NewSeeder(database.DBConn)
+// resolve orders the named seeders after their dependencies
+func resolve(names []string) ([]registration, error) {
+	registrations := make(map[string]registration, len(registry))
+	for _, registration := range registry {
+		registrations[registration.name] = registration
+	}
 
-	// User seeder
-	if err = seeder.userSeeder.Seed(); err != nil {
-		log.Fatal(err)
+	if len(names) == 0 {
+		for _, registration := range registry {
+			if runsIn(registration, config.AppConfig.Env) {
+				names = append(names, registration.name)
+			}
+		}
 	}
+
+	var (
+		ordered  []registration
+		visiting = map[string]bool{}
+		visited  = map[string]bool{}
+		visit    func(name string) error
+	)
+	visit = func(name string) error {
+		registration, ok := registrations[name]
+		switch {
+		case !ok:
+			return fmt.Errorf("unknown seeder %q", name)
+		case visited[name]:
+			return nil
+		case visiting[name]:
+			return fmt.Errorf("seeder %q depends on itself", name)
+		case !runsIn(registration, config.AppConfig.Env):
+			return fmt.Errorf("seeder %q doesn't run in the %q environment", name, config.AppConfig.Env)
+		}
+
+		visiting[name] = true
+		for _, dependency := range registration.dependsOn {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		visited[name] = true
+		ordered = append(ordered, registration)
+
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+func runsIn(registration registration, env string) bool {
+	return len(registration.environments) == 0 || slices.Contains(registration.environments, env)
 }
@@ -5,43 +5,60 @@ import (
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type (
-	UserSeeder interface {
-		Seed() error
-		Clear() error
-	}
-	userSeeder struct {
-		db *gorm.DB
-	}
-)
+type userSeeder struct {
+	db *gorm.DB
+}
 
-func NewUserSeeder(db *gorm.DB) UserSeeder {
+func NewUserSeeder(db *gorm.DB) Seeder {
 	return userSeeder{db: db}
 }
 
+// seedUsers are the accounts every environment starts with
+var seedUsers = []models.User{
+	{
+		FirstName: "Super",
+		LastName:  "Administrator",
+		Email:     "superadmin@stream.co.th",
+		Role:      models.RoleAdmin,
+	},
+}
+
 // Implement seed method
 func (s userSeeder) Seed() error {
 	log.Println("UserSeeder running...")
 
-	user := models.User{
-		FirstName: "Super",
-		LastName:  "Administrator",
-		Email:     "superadmin@stream.co.th",
-	}
+	users := make([]models.User, len(seedUsers))
+	copy(users, seedUsers)
 
-	result := s.db.Create(&user)
+	// Upsert by email, which also restores deleted seed users
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"first_name", "last_name", "role", "updated_at", "deleted_at"}),
+	}).Create(&users)
+	if result.Error != nil {
+		return result.Error
+	}
 	log.Println("UserSeeder seeded!")
 
-	return result.Error
+	return nil
 }
 
 // Implement clear method
 func (s userSeeder) Clear() error {
 	log.Println("Clear UserSeeder...")
-	result := s.db.Delete(&models.User{})
+
+	emails := make([]string, 0, len(seedUsers))
+	for _, user := range seedUsers {
+		emails = append(emails, user.Email)
+	}
+	result := s.db.Unscoped().Where(`email IN ?`, emails).Delete(&models.User{})
+	if result.Error != nil {
+		return result.Error
+	}
 	log.Println("UserSeeder cleared!")
 
-	return result.Error
+	return nil
 }
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Serialization failures and deadlocks succeed when the transaction is retried
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// Transactor runs several repository calls as one unit of work
+type Transactor interface {
+	// Transaction runs fn in a transaction, the repositories called with the
+	// context passed to fn use the transaction. Transactions nested in fn run
+	// in a savepoint, and the outermost transaction is retried from the start
+	// after a serialization failure or deadlock.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	db       *gorm.DB
+	attempts int
+}
+
+func NewTransactor(db *gorm.DB) Transactor {
+	return transactor{db: db, attempts: 3}
+}
+
+// unitOfWorkKey is the context key of the transaction of a unit of work
+type unitOfWorkKey struct{}
+
+type unitOfWork struct {
+	tx *gorm.DB
+	// Shared with the nested units, set by the statements which failed to serialize
+	conflict *atomic.Bool
+}
+
+func unitOfWorkOf(ctx context.Context) *unitOfWork {
+	if ctx == nil {
+		return nil
+	}
+	unit, _ := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+
+	return unit
+}
+
+// Conn returns the transaction of the unit of work in ctx, or db outside of one
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if unit := unitOfWorkOf(ctx); unit != nil {
+		return unit.tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
+
+func (t transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if outer := unitOfWorkOf(ctx); outer != nil {
+		return outer.tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, unitOfWorkKey{}, &unitOfWork{tx: tx, conflict: outer.conflict}))
+		})
+	}
+
+	for attempt := 1; ; attempt++ {
+		unit := &unitOfWork{conflict: &atomic.Bool{}}
+		err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			unit.tx = tx
+			return fn(context.WithValue(ctx, unitOfWorkKey{}, unit))
+		})
+		if err == nil || attempt >= t.attempts || !(unit.conflict.Load() || isSerializationFailure(err)) {
+			return err
+		}
+
+		// Back off with jitter so the conflicting transactions don't collide again
+		backoff := time.Duration(attempt*10+rand.Intn(10)) * time.Millisecond
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
+
+// registerUnitOfWork flags the unit of work of a statement which failed to
+// serialize, as the repositories don't return the database errors
+func registerUnitOfWork(db *gorm.DB) error {
+	conflict := func(db *gorm.DB) {
+		if unit := unitOfWorkOf(db.Statement.Context); unit != nil && isSerializationFailure(db.Error) {
+			unit.conflict.Store(true)
+		}
+	}
+
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Create().After("gorm:create").Register("database:conflict", conflict),
+		callback.Query().After("gorm:query").Register("database:conflict", conflict),
+		callback.Update().After("gorm:update").Register("database:conflict", conflict),
+		callback.Delete().After("gorm:delete").Register("database:conflict", conflict),
+		callback.Row().After("gorm:row").Register("database:conflict", conflict),
+		callback.Raw().After("gorm:raw").Register("database:conflict", conflict),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	AccountHandler interface {
+		// Account handlers
+		RequestEmailVerification(c *fiber.Ctx) error
+		VerifyEmail(c *fiber.Ctx) error
+		ForgotPassword(c *fiber.Ctx) error
+		ResetPassword(c *fiber.Ctx) error
+	}
+)
+
+func (h handler) RequestEmailVerification(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "RequestEmailVerificationHandler", trace.WithAttributes(attribute.String("handler", "RequestEmailVerification")))
+	)
+
+	userID, ok := middlewares.GetPrincipal(c).UserID()
+	if !ok {
+		return fiber.ErrForbidden
+	}
+
+	// Call service function
+	if err := h.accountService.RequestEmailVerification(ctx, userID, c.IP()); err != nil {
+		return accountError(err)
+	}
+
+	span.End()
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func (h handler) VerifyEmail(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "VerifyEmailHandler", trace.WithAttributes(attribute.String("handler", "VerifyEmail")))
+	)
+
+	// Create data transfer object
+	verifyEmailDto := new(services.VerifyEmailDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(verifyEmailDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*verifyEmailDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	userID, err := h.accountService.VerifyEmail(ctx, verifyEmailDto.Token)
+	if err != nil {
+		return accountError(err)
+	}
+
+	// email_verified_at is part of the cached user
+	h.cacher.Tag(userCacheTag(userID), UsersCacheTag).Flush(ctx)
+
+	span.End()
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func (h handler) ForgotPassword(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "ForgotPasswordHandler", trace.WithAttributes(attribute.String("handler", "ForgotPassword")))
+	)
+
+	// Create data transfer object
+	forgotPasswordDto := new(services.ForgotPasswordDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(forgotPasswordDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*forgotPasswordDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	if err := h.accountService.RequestPasswordReset(ctx, forgotPasswordDto.Email, c.IP()); err != nil {
+		return accountError(err)
+	}
+
+	// Same response whether or not the email is registered
+	span.End()
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func (h handler) ResetPassword(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "ResetPasswordHandler", trace.WithAttributes(attribute.String("handler", "ResetPassword")))
+	)
+
+	// Create data transfer object
+	resetPasswordDto := new(services.ResetPasswordDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(resetPasswordDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*resetPasswordDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	userID, err := h.accountService.ResetPassword(ctx, resetPasswordDto.Token, resetPasswordDto.Password)
+	if err != nil {
+		return accountError(err)
+	}
+
+	// The reset also verifies the email, which is part of the cached user
+	h.cacher.Tag(userCacheTag(userID), UsersCacheTag).Flush(ctx)
+
+	span.End()
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func accountError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidUserToken):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, services.ErrEmailAlreadyVerified):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrTooManyRequests):
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	}
+
+	utils.HandleErrors(err)
+	return fiber.ErrInternalServerError
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type (
+	APIKeyHandler interface {
+		// API key handlers
+		GetAPIKeys(c *fiber.Ctx) error
+		GetAPIKey(c *fiber.Ctx) error
+		CreateAPIKey(c *fiber.Ctx) error
+		UpdateAPIKey(c *fiber.Ctx) error
+		RotateAPIKey(c *fiber.Ctx) error
+		RevokeAPIKey(c *fiber.Ctx) error
+	}
+)
+
+func (h handler) GetAPIKeys(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "GetAPIKeysHandler", trace.WithAttributes(attribute.String("handler", "GetAPIKeys")))
+	)
+
+	// Get paginate values
+	paginate := queryPagination(c)
+	search := c.Query("search")
+
+	// Key records are security sensitive, always read them fresh
+	responseData, err := h.apiKeyService.GetAPIKeys(ctx, paginate, search)
+	if err != nil {
+		var queryErr *database.QueryError
+		if errors.As(err, &queryErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	span.End()
+	return c.JSON(responseData)
+}
+
+func (h handler) GetAPIKey(c *fiber.Ctx) error {
+	var (
+		id, _     = c.ParamsInt("id")
+		ctx, span = tracing.Tracer.Start(c.Context(), "GetAPIKeyHandler", trace.WithAttributes(attribute.String("handler", "GetAPIKey"), attribute.Int("id", id)))
+	)
+
+	responseData, err := h.apiKeyService.GetAPIKey(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fiber.ErrNotFound
+		}
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	span.End()
+	return c.JSON(responseData)
+}
+
+func (h handler) CreateAPIKey(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "CreateAPIKeyHandler", trace.WithAttributes(attribute.String("handler", "CreateAPIKey")))
+	)
+
+	// Create data transfer object
+	apiKeyDto := new(services.APIKeyDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(apiKeyDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*apiKeyDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	issued, err := h.apiKeyService.CreateAPIKey(ctx, apiKeyDto)
+	if err != nil {
+		utils.HandleErrors(err)
+		return err
+	}
+
+	span.End()
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+		"data":    issued,
+	})
+}
+
+func (h handler) UpdateAPIKey(c *fiber.Ctx) error {
+	var (
+		id, _     = c.ParamsInt("id")
+		ctx, span = tracing.Tracer.Start(c.Context(), "UpdateAPIKeyHandler", trace.WithAttributes(attribute.String("handler", "UpdateAPIKey"), attribute.Int("id", id)))
+	)
+
+	// Create data transfer object
+	apiKeyDto := new(services.APIKeyDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(apiKeyDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*apiKeyDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	err := h.apiKeyService.UpdateAPIKey(ctx, id, apiKeyDto)
+	if err != nil {
+		utils.HandleErrors(err)
+		return err
+	}
+
+	span.End()
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func (h handler) RotateAPIKey(c *fiber.Ctx) error {
+	var (
+		id, _     = c.ParamsInt("id")
+		ctx, span = tracing.Tracer.Start(c.Context(), "RotateAPIKeyHandler", trace.WithAttributes(attribute.String("handler", "RotateAPIKey"), attribute.Int("id", id)))
+	)
+
+	// Call service function
+	issued, err := h.apiKeyService.RotateAPIKey(ctx, id)
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyInactive) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		utils.HandleErrors(err)
+		return err
+	}
+
+	span.End()
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+		"data":    issued,
+	})
+}
+
+func (h handler) RevokeAPIKey(c *fiber.Ctx) error {
+	var (
+		id, _     = c.ParamsInt("id")
+		ctx, span = tracing.Tracer.Start(c.Context(), "RevokeAPIKeyHandler", trace.WithAttributes(attribute.String("handler", "RevokeAPIKey"), attribute.Int("id", id)))
+	)
+
+	// Call service function
+	err := h.apiKeyService.RevokeAPIKey(ctx, id)
+	if err != nil {
+		utils.HandleErrors(err)
+		return err
+	}
+
+	span.End()
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	AuditHandler interface {
+		// Audit handlers
+		GetAuditLogs(c *fiber.Ctx) error
+	}
+)
+
+// GetAuditLogs lists the audit trail, filtered by entity, actor and time range
+// with e.g. filter[entity][eq]=users&filter[created_at][gte]=2024-01-01
+func (h handler) GetAuditLogs(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "GetAuditLogsHandler", trace.WithAttributes(attribute.String("handler", "GetAuditLogs")))
+	)
+
+	// Get paginate values
+	paginate := queryPagination(c)
+
+	// Audit entries are always read fresh
+	responseData, err := h.auditLogService.GetAuditLogs(ctx, paginate)
+	if err != nil {
+		var queryErr *database.QueryError
+		if errors.As(err, &queryErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	span.End()
+	return c.JSON(responseData)
+}
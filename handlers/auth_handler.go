@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	AuthHandler interface {
+		// Auth handlers
+		Login(c *fiber.Ctx) error
+		LoginMFA(c *fiber.Ctx) error
+	}
+)
+
+func (h handler) Login(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "LoginHandler", trace.WithAttributes(attribute.String("handler", "Login")))
+	)
+
+	// Create data transfer object
+	loginDto := new(services.LoginDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(loginDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*loginDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	result, err := h.authService.Login(ctx, loginDto, c.IP())
+	if err != nil {
+		return authError(c, err)
+	}
+
+	span.End()
+	return c.JSON(result)
+}
+
+func (h handler) LoginMFA(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "LoginMFAHandler", trace.WithAttributes(attribute.String("handler", "LoginMFA")))
+	)
+
+	// Create data transfer object
+	loginMFADto := new(services.LoginMFADto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(loginMFADto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*loginMFADto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	result, err := h.authService.LoginMFA(ctx, loginMFADto, c.IP())
+	if err != nil {
+		return authError(c, err)
+	}
+
+	span.End()
+	return c.JSON(result)
+}
+
+// authError maps authentication failures to 401, throttling to 429 and logs everything else
+func authError(c *fiber.Ctx, err error) error {
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(throttled.RetryAfter.Seconds())))
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	}
+
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials),
+		errors.Is(err, services.ErrInvalidMFAToken),
+		errors.Is(err, services.ErrInvalidMFACode),
+		errors.Is(err, services.ErrMFANotEnrolled):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	utils.HandleErrors(err)
+	return fiber.ErrInternalServerError
+}
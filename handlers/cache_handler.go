@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	CacheHandler interface {
+		// Cache administration handlers
+		GetCacheStats(c *fiber.Ctx) error
+		GetCacheTags(c *fiber.Ctx) error
+		GetCacheKey(c *fiber.Ctx) error
+		FlushCacheTag(c *fiber.Ctx) error
+		PurgeCacheKeys(c *fiber.Ctx) error
+	}
+
+	cacheKeyQuery struct {
+		Key string `query:"key" validate:"required"`
+	}
+
+	cachePurgeQuery struct {
+		Pattern string `query:"pattern" validate:"required,max=200"`
+	}
+)
+
+func (h handler) GetCacheStats(c *fiber.Ctx) error {
+	_, span := tracing.Tracer.Start(c.Context(), "GetCacheStatsHandler", trace.WithAttributes(attribute.String("handler", "GetCacheStats")))
+
+	// Totals are per process, every prefork child and replica keeps its own
+	responseData := h.cacher.Stats()
+
+	span.End()
+	return c.JSON(fiber.Map{
+		"data": responseData,
+	})
+}
+
+func (h handler) GetCacheTags(c *fiber.Ctx) error {
+	ctx, span := tracing.Tracer.Start(c.Context(), "GetCacheTagsHandler", trace.WithAttributes(attribute.String("handler", "GetCacheTags")))
+
+	responseData, err := h.cacher.Tags(ctx)
+	if err != nil {
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	span.End()
+	return c.JSON(fiber.Map{
+		"data": responseData,
+	})
+}
+
+func (h handler) GetCacheKey(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "GetCacheKeyHandler", trace.WithAttributes(attribute.String("handler", "GetCacheKey")))
+		query     = new(cacheKeyQuery)
+	)
+
+	if err := c.QueryParser(query); err != nil {
+		return err
+	}
+
+	// Query validation
+	errors := utils.Validate(*query)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	responseData, err := h.cacher.Inspect(ctx, query.Key)
+	if err != nil {
+		if err == cache.ErrMiss {
+			return fiber.ErrNotFound
+		}
+		if err == cache.ErrInternalKey {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	span.End()
+	return c.JSON(fiber.Map{
+		"data": responseData,
+	})
+}
+
+func (h handler) FlushCacheTag(c *fiber.Ctx) error {
+	var (
+		tag       = c.Params("tag")
+		ctx, span = tracing.Tracer.Start(c.Context(), "FlushCacheTagHandler", trace.WithAttributes(attribute.String("handler", "FlushCacheTag"), attribute.String("tag", tag)))
+	)
+
+	err := h.cacher.Tag(tag).Flush(ctx)
+	if err != nil {
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	span.End()
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func (h handler) PurgeCacheKeys(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "PurgeCacheKeysHandler", trace.WithAttributes(attribute.String("handler", "PurgeCacheKeys")))
+		query     = new(cachePurgeQuery)
+	)
+
+	if err := c.QueryParser(query); err != nil {
+		return err
+	}
+
+	// Query validation
+	errors := utils.Validate(*query)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	purged, err := h.cacher.Purge(ctx, query.Pattern)
+	if err != nil {
+		if err == cache.ErrInternalKey {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	span.End()
+	return c.JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+		"data": fiber.Map{
+			"purged": purged,
+		},
+	})
+}
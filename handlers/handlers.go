@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"log"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
@@ -14,84 +13,68 @@ import (
 type (
 	// Register handler services
 	handler struct {
-		cacher      *cache.Cache
-		userService services.UserService
+		cacher            *cache.Cache
+		userService       services.UserService
+		apiKeyService     services.APIKeyService
+		sessionService    services.SessionService
+		authService       services.AuthService
+		mfaService        services.MFAService
+		accountService    services.AccountService
+		loginGuardService services.LoginGuardService
+		auditLogService   services.AuditLogService
 	}
 	// Register handler interfaces
 	Handler interface {
 		UserHandler
+		APIKeyHandler
+		SessionHandler
+		AuthHandler
+		MFAHandler
+		AccountHandler
+		SecurityHandler
+		AuditHandler
+		CacheHandler
 	}
 )
 
 func NewHandler(
 	cacher *cache.Cache,
 	userService services.UserService,
+	apiKeyService services.APIKeyService,
+	sessionService services.SessionService,
+	authService services.AuthService,
+	mfaService services.MFAService,
+	accountService services.AccountService,
+	loginGuardService services.LoginGuardService,
+	auditLogService services.AuditLogService,
 ) handler {
 	return handler{
-		cacher:      cacher,
-		userService: userService,
+		cacher:            cacher,
+		userService:       userService,
+		apiKeyService:     apiKeyService,
+		sessionService:    sessionService,
+		authService:       authService,
+		mfaService:        mfaService,
+		accountService:    accountService,
+		loginGuardService: loginGuardService,
+		auditLogService:   auditLogService,
 	}
 }
 
-type ServicePaginationFunc func(ctx context.Context, paginate database.Pagination, search string) (*database.Pagination, error)
-type ServiceQueryFunc func(ctx context.Context, id int) (map[string]interface{}, error)
-
-func (h handler) PaginationCache(ctx context.Context, key string, tags []string, paginate database.Pagination, search string, f ServicePaginationFunc) (*database.Pagination, error) {
-	var (
-		responseData *database.Pagination
-		err          error
-	)
-
-	// Get the cached attributes object
-	err = cache.Cacher.Get(ctx, key, &responseData)
-	if err != nil {
-		return nil, err
-	}
-
-	if responseData == nil {
-		// Call service function
-		responseData, err = f(ctx, paginate, search)
-		if err != nil {
-			return nil, err
-		}
-
-		// Set cache
-		err = cache.Cacher.Tag(tags...).Set(ctx, key, &responseData)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return responseData, nil
-}
-
-func (h handler) QueryCache(ctx context.Context, key string, tags []string, id int, f ServiceQueryFunc) (map[string]interface{}, error) {
-	var (
-		responseData map[string]interface{}
-		err          error
-	)
-
-	// Get the cached attributes object
-	err = cache.Cacher.Get(ctx, key, &responseData)
-	if err != nil {
-		return nil, err
+// queryPagination reads the page, sort, filter[column][operator] and fields
+// query parameters, repositories validate them against their allowlist.
+// A cursor parameter, empty for the first page, switches to cursor pagination.
+func queryPagination(c *fiber.Ctx) database.Pagination {
+	return database.Pagination{
+		Page:       c.QueryInt("page", 1),
+		Limit:      c.QueryInt("limit", 20),
+		Sort:       c.Query("sort"),
+		Filters:    database.ParseFilters(c.Queries()),
+		Fields:     database.ParseFields(c.Query("fields")),
+		CursorMode: c.Request().URI().QueryArgs().Has("cursor"),
+		Cursor:     c.Query("cursor"),
+		Count:      c.Query("count"),
 	}
-
-	if responseData == nil {
-		// Call service function
-		responseData, err = f(ctx, id)
-		if err != nil {
-			return nil, err
-		}
-
-		// Set cache
-		err = cache.Cacher.Tag(tags...).Set(ctx, key, &responseData)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return responseData, nil
 }
 
 // Root handlers  ------------------------------------------------------------------
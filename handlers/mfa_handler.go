@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	MFAHandler interface {
+		// MFA handlers
+		EnrollTOTP(c *fiber.Ctx) error
+		ConfirmTOTP(c *fiber.Ctx) error
+		DisableMFA(c *fiber.Ctx) error
+		RegenerateRecoveryCodes(c *fiber.Ctx) error
+	}
+)
+
+func (h handler) EnrollTOTP(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "EnrollTOTPHandler", trace.WithAttributes(attribute.String("handler", "EnrollTOTP")))
+	)
+
+	// MFA is only managed for local accounts
+	userID, ok := middlewares.GetPrincipal(c).UserID()
+	if !ok {
+		return fiber.ErrForbidden
+	}
+
+	// Call service function
+	enrollment, err := h.mfaService.EnrollTOTP(ctx, userID)
+	if err != nil {
+		return mfaError(err)
+	}
+
+	span.End()
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": enrollment})
+}
+
+func (h handler) ConfirmTOTP(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "ConfirmTOTPHandler", trace.WithAttributes(attribute.String("handler", "ConfirmTOTP")))
+	)
+
+	userID, ok := middlewares.GetPrincipal(c).UserID()
+	if !ok {
+		return fiber.ErrForbidden
+	}
+
+	// Create data transfer object
+	mfaCodeDto := new(services.MFACodeDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(mfaCodeDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*mfaCodeDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	recoveryCodes, err := h.mfaService.ConfirmTOTP(ctx, userID, mfaCodeDto.Code)
+	if err != nil {
+		return mfaError(err)
+	}
+
+	// mfa_enabled is part of the cached user
+	h.cacher.Tag(userCacheTag(userID), UsersCacheTag).Flush(ctx)
+
+	span.End()
+	return c.JSON(fiber.Map{"data": fiber.Map{"recovery_codes": recoveryCodes}})
+}
+
+func (h handler) DisableMFA(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "DisableMFAHandler", trace.WithAttributes(attribute.String("handler", "DisableMFA")))
+	)
+
+	userID, ok := middlewares.GetPrincipal(c).UserID()
+	if !ok {
+		return fiber.ErrForbidden
+	}
+
+	// Create data transfer object
+	mfaCodeDto := new(services.MFACodeDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(mfaCodeDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*mfaCodeDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	if err := h.mfaService.DisableMFA(ctx, userID, mfaCodeDto.Code); err != nil {
+		return mfaError(err)
+	}
+
+	// mfa_enabled is part of the cached user
+	h.cacher.Tag(userCacheTag(userID), UsersCacheTag).Flush(ctx)
+
+	span.End()
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func (h handler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "RegenerateRecoveryCodesHandler", trace.WithAttributes(attribute.String("handler", "RegenerateRecoveryCodes")))
+	)
+
+	userID, ok := middlewares.GetPrincipal(c).UserID()
+	if !ok {
+		return fiber.ErrForbidden
+	}
+
+	// Create data transfer object
+	mfaCodeDto := new(services.MFACodeDto)
+
+	// Parse HTTP request body to struct variable
+	if err := c.BodyParser(mfaCodeDto); err != nil {
+		return err
+	}
+
+	// Form request validation
+	errors := utils.Validate(*mfaCodeDto)
+	if errors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errors)
+	}
+
+	// Call service function
+	recoveryCodes, err := h.mfaService.RegenerateRecoveryCodes(ctx, userID, mfaCodeDto.Code)
+	if err != nil {
+		return mfaError(err)
+	}
+
+	span.End()
+	return c.JSON(fiber.Map{"data": fiber.Map{"recovery_codes": recoveryCodes}})
+}
+
+func mfaError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, services.ErrMFAAlreadyEnabled),
+		errors.Is(err, services.ErrMFANotEnrolled):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+
+	utils.HandleErrors(err)
+	return fiber.ErrInternalServerError
+}
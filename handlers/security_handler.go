@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type (
+	SecurityHandler interface {
+		// Security handlers
+		GetSecurityEvents(c *fiber.Ctx) error
+		UnlockUser(c *fiber.Ctx) error
+	}
+)
+
+func (h handler) GetSecurityEvents(c *fiber.Ctx) error {
+	var (
+		ctx, span = tracing.Tracer.Start(c.Context(), "GetSecurityEventsHandler", trace.WithAttributes(attribute.String("handler", "GetSecurityEvents")))
+	)
+
+	// Get paginate values
+	paginate := queryPagination(c)
+	search := c.Query("search")
+
+	// Audit entries are always read fresh
+	responseData, err := h.loginGuardService.GetSecurityEvents(ctx, paginate, search)
+	if err != nil {
+		var queryErr *database.QueryError
+		if errors.As(err, &queryErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	span.End()
+	return c.JSON(responseData)
+}
+
+func (h handler) UnlockUser(c *fiber.Ctx) error {
+	var (
+		id, _     = c.ParamsInt("id")
+		principal = middlewares.GetPrincipal(c)
+		ctx, span = tracing.Tracer.Start(c.Context(), "UnlockUserHandler", trace.WithAttributes(attribute.String("handler", "UnlockUser"), attribute.Int("id", id)))
+	)
+
+	// Call service function
+	err := h.loginGuardService.Unlock(ctx, id, principal.Subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fiber.ErrNotFound
+		}
+		utils.HandleErrors(err)
+		return err
+	}
+
+	span.End()
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	SessionHandler interface {
+		// Session handlers
+		GetMySessions(c *fiber.Ctx) error
+		RevokeMySession(c *fiber.Ctx) error
+		RevokeMyOtherSessions(c *fiber.Ctx) error
+		SignOutUserEverywhere(c *fiber.Ctx) error
+	}
+)
+
+func (h handler) GetMySessions(c *fiber.Ctx) error {
+	var (
+		principal = middlewares.GetPrincipal(c)
+		ctx, span = tracing.Tracer.Start(c.Context(), "GetMySessionsHandler", trace.WithAttributes(attribute.String("handler", "GetMySessions")))
+	)
+
+	sessions, err := h.sessionService.GetSessions(ctx, principal.Subject)
+	if err != nil {
+		utils.HandleErrors(err)
+		return fiber.ErrInternalServerError
+	}
+
+	// Mark the session of this request
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == principal.SessionID
+	}
+
+	span.End()
+	return c.JSON(fiber.Map{"data": sessions})
+}
+
+func (h handler) RevokeMySession(c *fiber.Ctx) error {
+	var (
+		id        = c.Params("id")
+		principal = middlewares.GetPrincipal(c)
+		ctx, span = tracing.Tracer.Start(c.Context(), "RevokeMySessionHandler", trace.WithAttributes(attribute.String("handler", "RevokeMySession")))
+	)
+
+	// Call service function
+	err := h.sessionService.RevokeSession(ctx, principal.Subject, id)
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			return fiber.ErrNotFound
+		}
+		utils.HandleErrors(err)
+		return err
+	}
+
+	span.End()
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func (h handler) RevokeMyOtherSessions(c *fiber.Ctx) error {
+	var (
+		principal = middlewares.GetPrincipal(c)
+		ctx, span = tracing.Tracer.Start(c.Context(), "RevokeMyOtherSessionsHandler", trace.WithAttributes(attribute.String("handler", "RevokeMyOtherSessions")))
+	)
+
+	// Call service function
+	err := h.sessionService.RevokeOtherSessions(ctx, principal.Subject, principal.SessionID)
+	if err != nil {
+		utils.HandleErrors(err)
+		return err
+	}
+
+	span.End()
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+func (h handler) SignOutUserEverywhere(c *fiber.Ctx) error {
+	var (
+		id        = c.Params("id")
+		ctx, span = tracing.Tracer.Start(c.Context(), "SignOutUserEverywhereHandler", trace.WithAttributes(attribute.String("handler", "SignOutUserEverywhere"), attribute.String("id", id)))
+	)
+
+	// Call service function, the token subject is the user ID
+	err := h.sessionService.RevokeAllSessions(ctx, id)
+	if err != nil {
+		utils.HandleErrors(err)
+		return err
+	}
+
+	span.End()
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
@@ -24,28 +27,32 @@ type (
 	}
 )
 
+// List responses carry the collection tag, a user's detail carries its entity tag
+const UsersCacheTag = "users"
+
+func userCacheTag(id int) string {
+	return fmt.Sprintf("user:%d", id)
+}
+
 func (h handler) GetUsers(c *fiber.Ctx) error {
 	var (
 		ctx, span    = tracing.Tracer.Start(c.Context(), "GetUsersHandler", trace.WithAttributes(attribute.String("handler", "GetUsers")))
-		responseData *database.Pagination
+		responseData *database.Page[models.User]
 	)
 
 	// Get paginate values
-	paginate := database.Pagination{
-		Page:  c.QueryInt("page", 1),
-		Limit: c.QueryInt("limit", 20),
-	}
+	paginate := queryPagination(c)
 	search := c.Query("search")
 
-	// Make cache key
-	cacheTags := []string{"users"}
-	cacheKey := fmt.Sprintf("GetUsers_%d_%d", paginate.Page, paginate.Limit)
-	if search != "" {
-		cacheKey = fmt.Sprintf(`%s_%s`, cacheKey, search)
-	}
-
-	responseData, err := h.PaginationCache(ctx, cacheKey, cacheTags, paginate, search, h.userService.GetUsers)
+	// Pages are cached by the response cache of the route under the users tag
+	responseData, err := h.userService.GetUsers(ctx, paginate, search)
 	if err != nil {
+		var queryErr *database.QueryError
+		if errors.As(err, &queryErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
 		utils.HandleErrors(err)
 		return fiber.ErrInternalServerError
 	}
@@ -62,10 +69,12 @@ func (h handler) GetUser(c *fiber.Ctx) error {
 	)
 
 	// Make cache key
-	cacheTags := []string{"users"}
+	cacheTags := []string{userCacheTag(id)}
 	cacheKey := fmt.Sprintf("GetUser_%d", id)
 
-	responseData, err := h.QueryCache(ctx, cacheKey, cacheTags, id, h.userService.GetUser)
+	responseData, err := cache.Remember(ctx, h.cacher, cacheKey, 0, cacheTags, func(ctx context.Context) (map[string]interface{}, error) {
+		return h.userService.GetUser(ctx, id)
+	})
 	if err != nil {
 		utils.HandleErrors(err)
 		return fiber.ErrInternalServerError
@@ -101,8 +110,8 @@ func (h handler) CreateUser(c *fiber.Ctx) error {
 		return err
 	}
 
-	// Clear user cache
-	cache.Cacher.Tag("users").Flush(ctx)
+	// A new user only changes the list pages
+	h.cacher.Tag(UsersCacheTag).Flush(ctx)
 
 	span.End()
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -138,8 +147,8 @@ func (h handler) UpdateUser(c *fiber.Ctx) error {
 		return err
 	}
 
-	// Clear user cache
-	cache.Cacher.Tag("users").Flush(ctx)
+	// Clear the user's detail and the list pages
+	h.cacher.Tag(userCacheTag(id), UsersCacheTag).Flush(ctx)
 
 	span.End()
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -161,8 +170,8 @@ func (h handler) DeleteUser(c *fiber.Ctx) error {
 		return err
 	}
 
-	// Clear user cache
-	cache.Cacher.Tag("users").Flush(ctx)
+	// Clear the user's detail and the list pages
+	h.cacher.Tag(userCacheTag(id), UsersCacheTag).Flush(ctx)
 
 	span.End()
 	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
@@ -15,6 +16,7 @@ import (
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/routes"
 	"github.com/getsentry/sentry-go"
+	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -22,12 +24,20 @@ func main() {
 	// Migration flag
 	dbMigratePtr := flag.Bool("db-migrate", false, "a bool")
 	dbRollbackPtr := flag.Bool("db-rollback", false, "a bool")
-	dbSeederPtr := flag.Bool("db-seed", false, "a bool")
+	var dbSeed, dbReset seedFlag
+	flag.Var(&dbSeed, "db-seed", "run all seeders, or the comma separated seeders with -db-seed=users,members")
+	flag.Var(&dbReset, "db-reset", "clear and seed again, all seeders or the comma separated seeders")
 	flag.Parse()
 
 	// TODO: Load environment variables
 	config.LoadConfig()
 
+	// Run a migrate subcommand, see migrateUsage, and exit the application
+	if flag.Arg(0) == "migrate" {
+		runMigrateCommand(flag.Args()[1:])
+		os.Exit(0)
+	}
+
 	// Run database migration, if -db-migrate flag is set to TRUE. and exit the application
 	if *dbMigratePtr {
 		database.Migrate()
@@ -35,21 +45,42 @@ func main() {
 	}
 	// Run database rollback, if -db-rollback flag is set to TRUE. and exit the application
 	if *dbRollbackPtr {
+		confirmRollback(false, "roll back every migration and drop every table")
 		database.Rollback()
 		os.Exit(0)
 	}
-	// Run database seeder, if -db-seed flag is set to TRUE. and exit the application
-	if *dbSeederPtr {
-		seeders.RunSeed()
+	// Run database seeder, if -db-seed flag is set. and exit the application
+	if dbSeed.set {
+		seeders.RunSeed(dbSeed.names)
+		os.Exit(0)
+	}
+	// Clear the seeded rows and seed again, if -db-reset flag is set. and exit the application
+	if dbReset.set {
+		confirmRollback(false, "clear the seeded rows")
+		seeders.ResetSeed(dbReset.names)
 		os.Exit(0)
 	}
 
 	// Initialize connection to database and cache
 	database.DBConn = database.Initialize()
-	cache.Client = cache.Initialize()
-	cache.Cacher = cache.NewCache(
+	database.CheckMigrationVersion()
+	redisClient := cache.Initialize()
+	if redisClient == nil {
+		log.Fatal("CACHE_DRIVER=memory without REDIS_ADDR: sessions, API keys, MFA, login protection, " +
+			"accounts, rate limits and read-your-writes keep their state in Redis, set REDIS_ADDR or use another driver")
+	}
+	cacheCodec, err := cache.ParseCodec(config.AppConfig.CacheCodec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cacher := cache.NewCache(
+		cache.NewBackend(redisClient),
 		cache.WithPrefix(config.AppConfig.CachePrefix),
 		cache.WithExpired(time.Minute*time.Duration(config.AppConfig.CacheMinuteDuration)),
+		cache.WithEarlyRefresh(config.AppConfig.CacheEarlyRefresh),
+		cache.WithLocal(int64(config.AppConfig.CacheLocalMaxBytes), time.Second*time.Duration(config.AppConfig.CacheLocalSeconds)),
+		cache.WithCodec(cacheCodec),
+		cache.WithCompression(config.AppConfig.CacheCompressAbove),
 	)
 
 	// Initialize Sentry client for error logging and tracing
@@ -60,26 +91,55 @@ func main() {
 
 	// Initialize OpenTelemetry tracing
 	tracing.TraceProvider = tracing.InitTracer()
+	tracing.MeterProvider = tracing.InitMeter()
 	defer tracing.Cleanup()
 
 	// Create microservice instance
 	ms := microservices.NewMicroservice()
+	ms.OnCleanup(cacher.Close)
 
 	// HTTP Routes setup
 	routes.HTTPRootRoute(ms)
 
 	// Start http server
-	startHTTP(ms)
+	startHTTP(ms, redisClient, cacher)
 
 	// Microservice start up
 	ms.Start()
 }
 
-func startHTTP(ms *microservices.Microservice) {
+func startHTTP(ms *microservices.Microservice, redisClient redis.UniversalClient, cacher *cache.Cache) {
 	zone, _ := time.Now().Zone()
 	if !fiber.IsChild() {
 		log.Println("HTTP service is running")
 		log.Println("[Timezone]:", zone)
 	}
-	routes.HTTPRoutes(ms)
+	routes.HTTPRoutes(ms, redisClient, cacher)
+}
+
+// seedFlag is a bool flag which optionally names the seeders, e.g. -db-seed=users,members
+type seedFlag struct {
+	set   bool
+	names []string
+}
+
+func (f *seedFlag) String() string {
+	return strings.Join(f.names, ",")
+}
+
+func (f *seedFlag) Set(value string) error {
+	f.set, f.names = value != "false", nil
+	if value != "true" && value != "false" {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				f.names = append(f.names, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *seedFlag) IsBoolFlag() bool {
+	return true
 }
@@ -10,7 +10,6 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/csrf"
 	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
@@ -28,7 +27,6 @@ func (ms *Microservice) startHTTP(exitChannel chan bool) error {
 	fiberRequestID := requestid.New()
 	fiberLogger := logger.New(config.LoggerConfig)
 	fiberRecover := recover.New()
-	fiberLimiter := limiter.New(config.LimiterConfig)
 	fiberETag := etag.New(config.ETagConfig)
 	fiberCors := cors.New(config.CorsConfig)
 	fiberCsrf := csrf.New(config.CsrfConfig)
@@ -37,7 +35,6 @@ func (ms *Microservice) startHTTP(exitChannel chan bool) error {
 	ms.fiber.Use(fiberRequestID)
 	ms.fiber.Use(fiberLogger)
 	ms.fiber.Use(fiberRecover)
-	ms.fiber.Use(fiberLimiter)
 	ms.fiber.Use(fiberETag)
 	ms.fiber.Use(fiberCors)
 	ms.fiber.Use(fiberCsrf)
@@ -9,7 +9,6 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
 	"github.com/gofiber/fiber/v2"
@@ -38,6 +37,7 @@ type IMicroservice interface {
 type Microservice struct {
 	fiber       *fiber.App
 	exitChannel chan bool
+	cleanups    []func() error
 }
 
 // ServiceHandleFunc is the handler for each Microservice
@@ -109,13 +109,20 @@ func (ms *Microservice) Cleanup() error {
 		sqlDB, _ := database.DBConn.DB()
 		sqlDB.Close()
 	}
-	if cache.Client != nil {
-		cache.Client.Close()
+	for _, cleanup := range ms.cleanups {
+		if err := cleanup(); err != nil {
+			ms.Log("Microservices", err.Error())
+		}
 	}
 
 	return nil
 }
 
+// OnCleanup registers a function to release a resource on Cleanup
+func (ms *Microservice) OnCleanup(cleanup func() error) {
+	ms.cleanups = append(ms.cleanups, cleanup)
+}
+
 // Log log message to console
 func (ms *Microservice) Log(tag string, message string) {
 	_, fn, line, _ := runtime.Caller(1)
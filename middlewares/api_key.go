@@ -0,0 +1,120 @@
+package middlewares
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// Protected accepts either an API key, for machine clients such as kiosks and
+// turnstiles, or an OAuth bearer token
+func Protected(apiKeyService services.APIKeyService, sessionService services.SessionService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if GetPrincipal(c) != nil {
+			return c.Next()
+		}
+
+		if rawKey := apiKeyFromRequest(c); rawKey != "" {
+			return apiKeyAuthentication(c, apiKeyService, rawKey)
+		}
+
+		return authentication(c, sessionService)
+	}
+}
+
+// Identify sets the principal when the request carries valid credentials, so that
+// public routes can be limited per caller. Invalid credentials are not rejected
+// here, the caller stays anonymous and protected route groups reject them.
+func Identify(apiKeyService services.APIKeyService, sessionService services.SessionService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var (
+			principal *Principal
+			err       error
+		)
+
+		if rawKey := apiKeyFromRequest(c); rawKey != "" {
+			principal, err = apiKeyPrincipal(c, apiKeyService, rawKey)
+		} else if c.Get(fiber.HeaderAuthorization) != "" {
+			principal, err = bearerPrincipal(c, sessionService)
+		} else {
+			return c.Next()
+		}
+
+		var authErr *authError
+		if errors.As(err, &authErr) &&
+			(authErr.status == fiber.StatusUnauthorized || authErr.status == utils.StatusInvalidToken) {
+			return c.Next()
+		}
+		if err != nil {
+			return rejectAuthentication(c, err)
+		}
+
+		c.Locals(PrincipalKey, principal)
+
+		return c.Next()
+	}
+}
+
+func apiKeyFromRequest(c *fiber.Ctx) string {
+	if rawKey := c.Get(APIKeyHeader); rawKey != "" {
+		return rawKey
+	}
+
+	// Some door controllers can only set the Authorization header
+	if rawKey, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "ApiKey "); found {
+		return rawKey
+	}
+
+	return ""
+}
+
+func apiKeyAuthentication(c *fiber.Ctx, apiKeyService services.APIKeyService, rawKey string) error {
+	principal, err := apiKeyPrincipal(c, apiKeyService, rawKey)
+	if err != nil {
+		return rejectAuthentication(c, err)
+	}
+
+	c.Locals(PrincipalKey, principal)
+
+	return c.Next()
+}
+
+// apiKeyPrincipal verifies the API key and consumes a request from its rate limit
+func apiKeyPrincipal(c *fiber.Ctx, apiKeyService services.APIKeyService, rawKey string) (*Principal, error) {
+	apiKey, err := apiKeyService.Authenticate(c.Context(), rawKey)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKey) ||
+			errors.Is(err, services.ErrAPIKeyRevoked) ||
+			errors.Is(err, services.ErrAPIKeyExpired) {
+			return nil, &authError{status: fiber.StatusUnauthorized, body: fiber.Map{
+				"message": err.Error(),
+			}}
+		}
+		return nil, err
+	}
+
+	// Per-key rate limit
+	if !slices.Contains(apiKey.Scopes, ScopeRateLimitBypass) {
+		rateLimit, err := apiKeyService.Allow(c.Context(), apiKey)
+		if err != nil {
+			return nil, err
+		}
+		setRateLimitHeaders(c, rateLimit)
+		if !rateLimit.Allowed {
+			return nil, &authError{status: fiber.StatusTooManyRequests}
+		}
+	}
+
+	return &Principal{
+		Type:     PrincipalTypeAPIKey,
+		Subject:  apiKey.Prefix,
+		Scopes:   apiKey.Scopes,
+		APIKeyID: apiKey.ID,
+	}, nil
+}
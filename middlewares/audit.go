@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Audit records who makes the changes of a request, for the audit log which
+// the database writes for every change. It runs after the principal is known.
+func Audit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := &database.AuditActor{Type: "anonymous", IP: c.IP()}
+		if requestID, ok := c.Locals("requestid").(string); ok {
+			actor.RequestID = requestID
+		}
+		if principal := GetPrincipal(c); principal != nil {
+			actor.Type, actor.ID = principal.Type, principal.Subject
+		}
+		c.Locals(database.AuditKey, actor)
+
+		return c.Next()
+	}
+}
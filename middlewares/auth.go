@@ -3,20 +3,69 @@ package middlewares
 import (
 	"crypto/rsa"
 	"errors"
+	"fmt"
 	"log"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthProtected(c *fiber.Ctx) error {
-	return authentication(c)
+// AuthProtected authenticates users by OAuth bearer token
+func AuthProtected(sessionService services.SessionService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return authentication(c, sessionService)
+	}
+}
+
+// authError is a rejected credential and the response it is answered with
+type authError struct {
+	status int
+	body   interface{}
+}
+
+func (e *authError) Error() string {
+	return fmt.Sprintf("authentication failed with status %d", e.status)
+}
+
+// rejectAuthentication answers a request whose credentials could not be verified
+func rejectAuthentication(c *fiber.Ctx, err error) error {
+	var authErr *authError
+	if !errors.As(err, &authErr) {
+		utils.HandleErrors(err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	if authErr.body == nil {
+		return c.SendStatus(authErr.status)
+	}
+	return c.Status(authErr.status).JSON(authErr.body)
 }
 
-func authentication(c *fiber.Ctx) error {
+func authentication(c *fiber.Ctx, sessionService services.SessionService) error {
+	// The token was already verified by Identify
+	if principal := GetPrincipal(c); principal != nil && principal.Type == PrincipalTypeUser {
+		return c.Next()
+	}
+
+	principal, err := bearerPrincipal(c, sessionService)
+	if err != nil {
+		return rejectAuthentication(c, err)
+	}
+
+	// TODO: Load user from database
+	c.Locals(PrincipalKey, principal)
+
+	return c.Next()
+}
+
+// bearerPrincipal verifies the OAuth bearer token of the request
+func bearerPrincipal(c *fiber.Ctx, sessionService services.SessionService) (*Principal, error) {
 	var (
 		key         *rsa.PublicKey
 		bearerToken string
@@ -29,13 +78,13 @@ func authentication(c *fiber.Ctx) error {
 	} else if c.Get("authorization") != "" {
 		bearerToken = c.Get("authorization")
 	} else {
-		return c.SendStatus(fiber.StatusUnauthorized)
+		return nil, &authError{status: fiber.StatusUnauthorized}
 	}
 
 	// Split the bearer token
 	split := strings.Split(bearerToken, " ")
 	if len(split) < 2 {
-		return c.SendStatus(utils.StatusInvalidToken)
+		return nil, &authError{status: utils.StatusInvalidToken}
 	}
 
 	// Set JWT Token
@@ -49,7 +98,7 @@ func authentication(c *fiber.Ctx) error {
 	if err != nil {
 		// Invalid public key
 		log.Println("ParseRSAPublicKeyFromPEM Error:", err)
-		return c.SendStatus(fiber.StatusInternalServerError)
+		return nil, &authError{status: fiber.StatusInternalServerError}
 	}
 
 	// Verify the signature
@@ -61,16 +110,85 @@ func authentication(c *fiber.Ctx) error {
 	})
 	if err != nil {
 		// 401, Unexpected method token algorithm
-		return c.Status(fiber.StatusUnauthorized).JSON(err)
+		return nil, &authError{status: fiber.StatusUnauthorized, body: err}
 	}
 
-	// TODO: using claims data for get user from database
-	_, ok := token.Claims.(jwt.MapClaims)
+	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return c.Status(fiber.StatusUnauthorized).JSON(errors.New("invalid token"))
+		return nil, &authError{status: fiber.StatusUnauthorized, body: errors.New("invalid token")}
 	}
 
-	// TODO: Load user from database
+	// MFA challenges are signed with the same key but are not access tokens
+	if _, ok := claims[services.TokenUseClaim]; ok {
+		return nil, &authError{status: fiber.StatusUnauthorized}
+	}
+
+	principal := principalFromClaims(claims)
+
+	// Reject revoked tokens and keep track of the session
+	session := sessionFromClaims(claims)
+	session.IP = c.IP()
+	session.UserAgent = c.Get(fiber.HeaderUserAgent)
+	if err = sessionService.Verify(c.Context(), session); err != nil {
+		if errors.Is(err, services.ErrTokenRevoked) {
+			return nil, &authError{status: fiber.StatusUnauthorized, body: fiber.Map{
+				"message": err.Error(),
+			}}
+		}
+		return nil, err
+	}
+	principal.SessionID = session.ID
+
+	return principal, nil
+}
+
+// principalFromClaims reads the subject, roles and scopes of an OAuth access token
+func principalFromClaims(claims jwt.MapClaims) *Principal {
+	principal := &Principal{Type: PrincipalTypeUser}
+	principal.Subject, _ = claims.GetSubject()
+
+	switch roles := claims["roles"].(type) {
+	case []interface{}:
+		for _, role := range roles {
+			if role, ok := role.(string); ok {
+				principal.Roles = append(principal.Roles, role)
+			}
+		}
+	case string:
+		principal.Roles = strings.Fields(roles)
+	}
+
+	// OAuth 2.0 uses a space separated "scope" claim
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = strings.Fields(scope)
+	}
+
+	// Privileged scopes need a second factor for roles which require MFA
+	mfa := false
+	if methods, ok := claims["amr"].([]interface{}); ok {
+		for _, method := range methods {
+			if method == services.AuthMethodMFA {
+				mfa = true
+			}
+		}
+	}
+	principal.Scopes = services.FilterPrivilegedScopes(principal.Roles, principal.Scopes, mfa)
+
+	return principal
+}
+
+func sessionFromClaims(claims jwt.MapClaims) *services.Session {
+	session := &services.Session{LastSeenAt: time.Now()}
+	session.Subject, _ = claims.GetSubject()
+	session.ID, _ = claims["jti"].(string)
+
+	// GetIssuedAt would truncate the milliseconds of first-party tokens
+	if issuedAt, ok := claims["iat"].(float64); ok {
+		session.IssuedAt = time.UnixMilli(int64(math.Round(issuedAt * 1000)))
+	}
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		session.ExpiresAt = expiresAt.Time
+	}
 
-	return nil
+	return session
 }
@@ -0,0 +1,169 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+// The in-flight lock is extended while the handler runs
+const idempotencyLockTTL = 30 * time.Second
+
+// secretResponseKey marks the requests whose responses carry secrets
+type secretResponseKey struct{}
+
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Location    string `json:"location,omitempty"`
+	Body        []byte `json:"body"`
+	// Secret responses are never stored, only that the request succeeded
+	Secret bool `json:"secret,omitempty"`
+}
+
+// SecretResponse marks routes which answer with secrets, such as tokens, API
+// keys and recovery codes. Their responses are not stored by Idempotency and
+// not cached by clients or proxies.
+func SecretResponse() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(secretResponseKey{}, true)
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		return c.Next()
+	}
+}
+
+// Idempotency stores the first successful response of a POST or PATCH that
+// carries an Idempotency-Key header and replays it for retries with the same
+// key. A retry while the first request is still running gets 409 and reusing
+// a key with a different body gets 422. Error responses are not stored so
+// the client can retry them. Routes marked with SecretResponse only record
+// that the request succeeded, their retries get 409 instead of the secrets.
+func Idempotency(cacher *cache.Cache, ttl time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" || (c.Method() != fiber.MethodPost && c.Method() != fiber.MethodPatch) {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Idempotency-Key must be at most 255 characters",
+			})
+		}
+
+		ctx := c.Context()
+		// The scope is the hash tag, so a cluster spreads the keys over its slots
+		cacheKey := cache.PrivateNamespace + "idempotency:{" + idempotencyScope(c, key) + "}"
+		fingerprint := sha256.Sum256(c.Body())
+		request := hex.EncodeToString(fingerprint[:])
+
+		if replayed, err := replayIdempotent(c, cacher, cacheKey, request); replayed || err != nil {
+			return err
+		}
+
+		lock, err := cacher.TryLock(ctx, cacheKey, idempotencyLockTTL)
+		if err != nil {
+			if errors.Is(err, cache.ErrLockNotAcquired) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"message": "A request with this Idempotency-Key is still in progress",
+				})
+			}
+			utils.HandleErrors(err)
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		defer lock.Unlock(context.Background())
+
+		// The first request may have finished between the lookup and the lock
+		if replayed, err := replayIdempotent(c, cacher, cacheKey, request); replayed || err != nil {
+			return err
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusBadRequest {
+			return nil
+		}
+
+		response := idempotentResponse{
+			Fingerprint: request,
+			Status:      status,
+		}
+		if secret, _ := c.Locals(secretResponseKey{}).(bool); secret {
+			response.Secret = true
+		} else {
+			response.ContentType = string(c.Response().Header.ContentType())
+			response.Location = string(c.Response().Header.Peek(fiber.HeaderLocation))
+			response.Body = append([]byte(nil), c.Response().Body()...)
+		}
+		if err := cacher.SetWithTTL(ctx, cacheKey, response, ttl); err != nil {
+			// The response is sent anyway, a retry will run the handler again
+			utils.HandleErrors(err)
+		}
+
+		return nil
+	}
+}
+
+// replayIdempotent sends the stored response for the key, if there is one
+func replayIdempotent(c *fiber.Ctx, cacher *cache.Cache, cacheKey string, fingerprint string) (bool, error) {
+	var stored *idempotentResponse
+	if err := cacher.Get(c.Context(), cacheKey, &stored); err != nil {
+		utils.HandleErrors(err)
+		return true, c.SendStatus(fiber.StatusInternalServerError)
+	}
+	if stored == nil {
+		return false, nil
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return true, c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "Idempotency-Key was already used with a different request body",
+		})
+	}
+
+	if stored.Secret {
+		return true, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "A request with this Idempotency-Key already succeeded, its response is not replayed",
+		})
+	}
+
+	c.Set(IdempotencyReplayedHeader, "true")
+	if stored.ContentType != "" {
+		c.Set(fiber.HeaderContentType, stored.ContentType)
+	}
+	if stored.Location != "" {
+		c.Set(fiber.HeaderLocation, stored.Location)
+	}
+
+	return true, c.Status(stored.Status).Send(stored.Body)
+}
+
+// idempotencyScope keeps keys of different callers and endpoints apart.
+// Before authentication has run the caller is identified by its credentials.
+func idempotencyScope(c *fiber.Ctx, key string) string {
+	caller := c.IP()
+	if principal := GetPrincipal(c); principal != nil {
+		caller = principal.Type + ":" + principal.Subject
+	} else if credentials := c.Get(fiber.HeaderAuthorization) + c.Get(APIKeyHeader); credentials != "" {
+		caller = credentials
+	}
+
+	scope := sha256.Sum256([]byte(caller + "\n" + c.Method() + "\n" + c.Path() + "\n" + key))
+
+	return hex.EncodeToString(scope[:])
+}
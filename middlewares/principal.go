@@ -0,0 +1,94 @@
+package middlewares
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// PrincipalKey is the fiber locals key holding the authenticated *Principal
+	PrincipalKey = "principal"
+
+	PrincipalTypeUser   = "user"
+	PrincipalTypeAPIKey = "api_key"
+
+	RoleAdmin = models.RoleAdmin
+)
+
+// Principal is the authenticated caller of a request, a user or an API key
+type Principal struct {
+	Type     string   `json:"type"`
+	Subject  string   `json:"subject"`
+	Roles    []string `json:"roles"`
+	Scopes   []string `json:"scopes"`
+	APIKeyID uint     `json:"api_key_id,omitempty"`
+	// SessionID is the jti of the access token
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// UserID returns the local user ID of first-party tokens, whose subject is the user ID
+func (p *Principal) UserID() (int, bool) {
+	if p.Type != PrincipalTypeUser {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(p.Subject)
+	if err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// GetPrincipal returns the authenticated principal or nil on public routes
+func GetPrincipal(c *fiber.Ctx) *Principal {
+	principal, _ := c.Locals(PrincipalKey).(*Principal)
+	return principal
+}
+
+// RequireRoles allows the request when the principal has any of the roles
+func RequireRoles(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+
+		for _, role := range roles {
+			if principal.HasRole(role) {
+				return c.Next()
+			}
+		}
+
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+}
+
+// RequireScopes allows the request when the principal has all of the scopes
+func RequireScopes(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				return c.SendStatus(fiber.StatusForbidden)
+			}
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ScopeRateLimitBypass exempts users and API keys from rate limits, e.g. for load tests
+const ScopeRateLimitBypass = "ratelimit:bypass"
+
+// RateLimitPolicy is the sliding window limit of a route group
+type RateLimitPolicy struct {
+	// Name keeps the counters of different policies apart
+	Name string
+	// Limit applies to anonymous callers by IP, 0 disables it
+	Limit int
+	// PrincipalLimit applies to authenticated users by subject, 0 disables it
+	PrincipalLimit int
+	Window         time.Duration
+}
+
+// RateLimit limits the requests of each caller to the policy. Users are limited
+// per subject wherever they connect from, anonymous callers per IP. API keys are
+// limited per key on authentication instead.
+func RateLimit(rateLimiterService services.RateLimiterService, policy RateLimitPolicy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, identity := policy.Limit, "ip:"+c.IP()
+		if principal := GetPrincipal(c); principal != nil {
+			if principal.Type == PrincipalTypeAPIKey || principal.HasScope(ScopeRateLimitBypass) {
+				return c.Next()
+			}
+			limit, identity = policy.PrincipalLimit, principal.Type+":"+principal.Subject
+		}
+		if limit <= 0 {
+			return c.Next()
+		}
+
+		rateLimit, err := rateLimiterService.Allow(c.Context(), policy.Name, identity, limit, policy.Window)
+		if err != nil {
+			utils.HandleErrors(err)
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		setRateLimitHeaders(c, rateLimit)
+		if !rateLimit.Allowed {
+			return c.SendStatus(fiber.StatusTooManyRequests)
+		}
+
+		return c.Next()
+	}
+}
+
+// setRateLimitHeaders sets the RateLimit headers of the IETF draft. When nested
+// route groups are limited, the headers describe the limit closest to running out.
+func setRateLimitHeaders(c *fiber.Ctx, rateLimit *services.RateLimitResult) {
+	if remaining, err := strconv.Atoi(c.GetRespHeader("RateLimit-Remaining")); err == nil &&
+		remaining < rateLimit.Remaining && rateLimit.Allowed {
+		return
+	}
+
+	reset := strconv.Itoa(int(math.Ceil(rateLimit.Reset.Seconds())))
+	c.Set("RateLimit-Limit", strconv.Itoa(rateLimit.Limit))
+	c.Set("RateLimit-Remaining", strconv.Itoa(rateLimit.Remaining))
+	c.Set("RateLimit-Reset", reset)
+	c.Set("RateLimit-Policy", strconv.Itoa(rateLimit.Limit)+";w="+strconv.Itoa(int(rateLimit.Window.Seconds())))
+	if !rateLimit.Allowed {
+		c.Set(fiber.HeaderRetryAfter, reset)
+	}
+}
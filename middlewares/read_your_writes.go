@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadYourWrites sends the reads of a caller to the primary database for the
+// window after one of their requests changed rows, so they don't read stale
+// data from a replica which hasn't caught up yet. The marker is shared by all
+// instances, a Redis error falls back to the replicas.
+func ReadYourWrites(client redis.UniversalClient, window time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := services.RedisKey("read-your-writes", readYourWritesCaller(c))
+		consistency := &database.Consistency{}
+
+		recent, err := client.Exists(c.Context(), key).Result()
+		if err != nil {
+			utils.HandleErrors(err)
+		}
+		consistency.Primary = recent > 0
+		c.Locals(database.ConsistencyKey, consistency)
+
+		err = c.Next()
+
+		if consistency.Wrote() {
+			if err := client.Set(c.Context(), key, 1, window).Err(); err != nil {
+				utils.HandleErrors(err)
+			}
+		}
+
+		return err
+	}
+}
+
+func readYourWritesCaller(c *fiber.Ctx) string {
+	principal := GetPrincipal(c)
+	if principal == nil {
+		return "ip:" + c.IP()
+	}
+
+	return principal.Type + ":" + principal.Subject
+}
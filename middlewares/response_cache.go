@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/gofiber/fiber/v2"
+	fibercache "github.com/gofiber/fiber/v2/middleware/cache"
+)
+
+// ResponseCache caches GET responses in the shared cache under the tags, so
+// writes which flush the tags also drop the cached responses. Responses are
+// cached per principal, handlers opt out for private data by answering with
+// Cache-Control: private or no-store.
+func ResponseCache(cacher *cache.Cache, tags ...string) fiber.Handler {
+	cacheConfig := config.CacheConfig
+	cacheConfig.Storage = cache.NewStorage(cacher.Tag(tags...))
+	cacheConfig.KeyGenerator = func(c *fiber.Ctx) string {
+		return config.CacheConfig.KeyGenerator(c) + ":" + responseCacheCaller(c)
+	}
+
+	return fibercache.New(cacheConfig)
+}
+
+func responseCacheCaller(c *fiber.Ctx) string {
+	principal := GetPrincipal(c)
+	if principal == nil {
+		return "anonymous"
+	}
+
+	return principal.Type + ":" + principal.Subject
+}
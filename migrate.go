@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+)
+
+const migrateUsage = `Usage: main migrate [-yes] <command>
+
+Commands:
+  status          list the migrations and the applied version
+  up [N]          apply all or the next N migrations
+  down N          roll back the last N migrations
+  goto VERSION    migrate up or down to VERSION
+  force VERSION   set VERSION without migrating, clears a dirty version, -1 for none
+  create NAME     create empty up and down SQL files named NAME
+`
+
+// runMigrateCommand runs a migrate subcommand, migrations which roll back in
+// production ask for confirmation unless -yes is set
+func runMigrateCommand(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	yes := flags.Bool("yes", false, "skip the confirmation of rollbacks in production")
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage) }
+	flags.Parse(args)
+
+	command, args := flags.Arg(0), flags.Args()
+	if len(args) > 0 {
+		args = args[1:]
+	}
+
+	var err error
+	switch command {
+	case "status":
+		err = migrationStatus()
+	case "up":
+		if len(args) == 0 {
+			database.Migrate()
+			return
+		}
+		err = database.MigrateSteps(migrateCount(flags, args))
+	case "down":
+		if len(args) == 0 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		n := migrateCount(flags, args)
+		confirmRollback(*yes, fmt.Sprintf("roll back the last %d migrations", n))
+		err = database.MigrateSteps(-n)
+	case "goto":
+		version := uint(migrateCount(flags, args))
+		current, _, versionErr := database.MigrationVersion()
+		if versionErr != nil {
+			log.Fatal(versionErr)
+		}
+		if version < current {
+			confirmRollback(*yes, fmt.Sprintf("roll back from version %d to %d", current, version))
+		}
+		err = database.MigrateTo(version)
+	case "force":
+		if len(args) == 0 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		version, parseErr := strconv.Atoi(args[0])
+		if parseErr != nil || version < -1 {
+			log.Fatalf("invalid version %q", args[0])
+		}
+		confirmRollback(*yes, fmt.Sprintf("force the version to %d without migrating", version))
+		err = database.ForceVersion(version)
+	case "create":
+		if len(args) == 0 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		up, down, createErr := database.CreateMigration(strings.Join(args, "_"))
+		if createErr != nil {
+			log.Fatal(createErr)
+		}
+		log.Println("Created", up)
+		log.Println("Created", down)
+		return
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Migration completed")
+}
+
+func migrationStatus() error {
+	migrations, version, dirty, err := database.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		state := "pending"
+		if migration.Applied {
+			state = "applied"
+		}
+		if dirty && migration.Version == version {
+			state = "dirty"
+		}
+		fmt.Printf("%-8s %d_%s\n", state, migration.Version, migration.Name)
+	}
+	if dirty {
+		fmt.Printf("Version %d is dirty, fix the schema by hand and run migrate force\n", version)
+	} else {
+		fmt.Printf("Version %d\n", version)
+	}
+
+	return nil
+}
+
+// migrateCount parses the first argument as a positive number
+func migrateCount(flags *flag.FlagSet, args []string) int {
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		log.Fatalf("invalid number %q", args[0])
+	}
+
+	return n
+}
+
+// confirmRollback asks to type the environment name before changing the
+// production schema, outside production it does nothing
+func confirmRollback(yes bool, action string) {
+	if yes || config.AppConfig.Env != "production" {
+		return
+	}
+
+	fmt.Printf("This will %s in %s, type %q to continue: ", action, config.AppConfig.Env, config.AppConfig.Env)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != config.AppConfig.Env {
+		log.Fatal("Migration cancelled")
+	}
+}
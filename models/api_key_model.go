@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type APIKey struct {
+	Model
+	Name          string         `json:"name"`
+	Prefix        string         `json:"prefix"`
+	KeyHash       string         `json:"-"`
+	Scopes        pq.StringArray `json:"scopes" gorm:"type:text[]"`
+	RateLimit     int            `json:"rate_limit"`
+	ExpiresAt     *time.Time     `json:"expires_at"`
+	LastUsedAt    *time.Time     `json:"last_used_at"`
+	RevokedAt     *time.Time     `json:"revoked_at"`
+	RotatedFromID *uint          `json:"rotated_from_id"`
+}
+
+// IsActive reports whether the key is neither revoked nor expired at the given time
+func (k APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditLog is an append-only record of a change to a row
+type AuditLog struct {
+	ID        uint   `json:"id" gorm:"primarykey"`
+	Action    string `json:"action"`
+	Entity    string `json:"entity"`
+	EntityID  string `json:"entity_id"`
+	ActorType string `json:"actor_type"`
+	ActorID   string `json:"actor_id"`
+	RequestID string `json:"request_id"`
+	IP        string `json:"ip" gorm:"column:ip"`
+	// Changes holds the changed columns as {"before": {...}, "after": {...}}
+	Changes   json.RawMessage `json:"changes" gorm:"type:jsonb"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	SecurityEventLoginSucceeded  = "login_succeeded"
+	SecurityEventLoginFailed     = "login_failed"
+	SecurityEventAccountLocked   = "account_locked"
+	SecurityEventAccountUnlocked = "account_unlocked"
+	SecurityEventIPBlocked       = "ip_blocked"
+)
+
+type SecurityEvent struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Event     string    `json:"event"`
+	UserID    *uint     `json:"user_id"`
+	Email     string    `json:"email"`
+	IP        string    `json:"ip" gorm:"column:ip"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
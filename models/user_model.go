@@ -1,8 +1,33 @@
 package models
 
+import "time"
+
+const (
+	RoleMember = "member"
+	RoleStaff  = "staff"
+	RoleAdmin  = "admin"
+)
+
+// RoleScopes are the scopes granted to first-party tokens of each role
+var RoleScopes = map[string][]string{
+	RoleMember: {"profile"},
+	RoleStaff:  {"profile", "members:read", "members:write", "payments:refund"},
+	RoleAdmin:  {"profile", "members:read", "members:write", "payments:refund", "admin"},
+}
+
 type User struct {
 	Model
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"email"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Email           string     `json:"email"`
+	Phone           *string    `json:"phone"`
+	MemberCode      *string    `json:"member_code"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PasswordHash    string     `json:"-"`
+	Role            string     `json:"role" gorm:"default:member"`
+	MFAEnabled      bool       `json:"mfa_enabled" gorm:"column:mfa_enabled"`
+	MFASecret       string     `json:"-" gorm:"column:mfa_secret"`
+	MFAEnabledAt    *time.Time `json:"mfa_enabled_at" gorm:"column:mfa_enabled_at"`
+	// Highlights are the matched fields of a search, marked up with <mark>
+	Highlights map[string]string `json:"highlights,omitempty" gorm:"-"`
 }
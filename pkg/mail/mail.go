@@ -0,0 +1,134 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+)
+
+const (
+	DriverSMTP   = "smtp"
+	DriverLog    = "log"
+	DriverMemory = "memory"
+)
+
+type (
+	// Sender delivers transactional emails
+	Sender interface {
+		Send(ctx context.Context, message Message) error
+	}
+	Message struct {
+		To      string
+		Subject string
+		Text    string
+	}
+)
+
+// NewSender returns the sender of the configured MAIL_DRIVER. Production
+// must deliver emails, the log driver would write the tokens of the links
+// to the logs instead, so any other driver stops the service.
+func NewSender() Sender {
+	if config.AppConfig.Env == "production" && config.AppConfig.MailDriver != DriverSMTP {
+		log.Fatalf("MAIL_DRIVER must be %q in production, got %q", DriverSMTP, config.AppConfig.MailDriver)
+	}
+
+	switch config.AppConfig.MailDriver {
+	case DriverSMTP:
+		return NewSMTPSender(
+			config.AppConfig.SMTPHost,
+			config.AppConfig.SMTPPort,
+			config.AppConfig.SMTPUsername,
+			config.AppConfig.SMTPPassword,
+			config.AppConfig.MailFrom,
+		)
+	case DriverMemory:
+		return NewMemorySender()
+	default:
+		return NewLogSender()
+	}
+}
+
+// SMTPSender sends emails through an SMTP relay
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPSender(host string, port string, username string, password string, from string) *SMTPSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPSender{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, message Message) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", s.from)
+	fmt.Fprintf(&body, "To: %s\r\n", message.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", message.Subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(message.Text)
+
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{message.To}, []byte(body.String()))
+}
+
+// LogSender prints emails to the log, for local development. Links carry
+// their tokens, so it must never run in production.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, message Message) error {
+	log.Printf("Mail to %s: %s\n%s", message.To, message.Subject, message.Text)
+	return nil
+}
+
+// MemorySender keeps sent emails in memory so tests can inspect them
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(ctx context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+// Messages returns a copy of the sent emails
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// Reset forgets the sent emails
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+}
@@ -0,0 +1,66 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA-1 test vectors of RFC 6238 appendix B
+var rfc6238Secret = []byte("12345678901234567890")
+
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "94287082"},
+	{1111111109, "07081804"},
+	{1111111111, "14050471"},
+	{1234567890, "89005924"},
+	{2000000000, "69279037"},
+	{20000000000, "65353130"},
+}
+
+func TestHOTPMatchesRFC6238(t *testing.T) {
+	for _, vector := range rfc6238Vectors {
+		step := Step(time.Unix(vector.unix, 0))
+		if got := hotp(rfc6238Secret, step, 8); got != vector.code {
+			t.Fatalf("hotp at %d = %s, want %s", vector.unix, got, vector.code)
+		}
+	}
+}
+
+func TestCodeHasDigits(t *testing.T) {
+	secret := encoding.EncodeToString(rfc6238Secret)
+
+	for _, vector := range rfc6238Vectors {
+		code, err := Code(secret, Step(time.Unix(vector.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Fewer digits keep the last digits of the same value
+		if want := vector.code[len(vector.code)-Digits:]; code != want {
+			t.Fatalf("code at %d = %s, want %s", vector.unix, code, want)
+		}
+	}
+}
+
+func TestValidateAcceptsSkew(t *testing.T) {
+	secret := encoding.EncodeToString(rfc6238Secret)
+	now := time.Unix(1234567890, 0)
+
+	previous, err := Code(secret, Step(now)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step, ok := Validate(secret, previous, now); !ok || step != Step(now)-1 {
+		t.Fatalf("Validate = %d, %v, want the previous step", step, ok)
+	}
+
+	stale, err := Code(secret, Step(now)-Skew-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Validate(secret, stale, now); ok {
+		t.Fatal("Validate accepted a code outside the skew")
+	}
+}
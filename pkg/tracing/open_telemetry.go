@@ -78,4 +78,9 @@ func Cleanup() {
 	if err := TraceProvider.Shutdown(context.Background()); err != nil {
 		log.Printf("Error shutting down tracer provider: %v", err)
 	}
+	if MeterProvider != nil {
+		if err := MeterProvider.Shutdown(context.Background()); err != nil {
+			log.Printf("Error shutting down meter provider: %v", err)
+		}
+	}
 }
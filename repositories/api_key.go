@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+)
+
+type (
+	APIKeyRepository interface {
+		GetAPIKeyPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Page[models.APIKey], error)
+		GetAPIKeyByID(ctx context.Context, id int) (models.APIKey, error)
+		GetAPIKeyByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+		CreateAPIKey(ctx context.Context, apiKey *models.APIKey) error
+		UpdateAPIKey(ctx context.Context, id int, apiKey *models.APIKey) error
+		TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) error
+		RevokeAPIKey(ctx context.Context, id int, revokedAt time.Time) error
+	}
+)
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return apiKeyRepository{db: db}
+}
+
+// apiKeyQuery lists the columns clients may sort, filter and select API keys by
+var apiKeyQuery = database.QueryAllowlist{
+	Sortable: []string{"id", "name", "prefix", "rate_limit", "created_at"},
+	Filterable: map[string][]string{
+		"name":         {database.OperatorEq, database.OperatorLike},
+		"prefix":       {database.OperatorEq},
+		"rate_limit":   {database.OperatorEq, database.OperatorGte, database.OperatorLte},
+		"revoked_at":   {database.OperatorNull},
+		"expires_at":   {database.OperatorNull, database.OperatorLt, database.OperatorGt},
+		"last_used_at": {database.OperatorNull, database.OperatorLt, database.OperatorGt},
+		"created_at":   {database.OperatorGt, database.OperatorGte, database.OperatorLt, database.OperatorLte},
+	},
+	Selectable: []string{"id", "name", "prefix", "scopes", "rate_limit", "expires_at", "last_used_at", "revoked_at", "rotated_from_id", "created_at", "updated_at"},
+}
+
+func (r apiKeyRepository) GetAPIKeyPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Page[models.APIKey], error) {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "GetAPIKeyPaginateRepository", trace.WithAttributes(attribute.String("repository", "GetAPIKeyPaginate"), attribute.String("search", search)))
+		err          error
+	)
+
+	if err = pagination.Validate(apiKeyQuery); err != nil {
+		return nil, err
+	}
+
+	// Pagination query
+	query := database.Conn(ctx, r.db).Model(&models.APIKey{})
+	if search != "" {
+		query = query.Where(`name LIKE ? OR prefix = ?`, fmt.Sprintf(`%%%s%%`, search), search)
+	}
+
+	page, err := database.Paginate[models.APIKey](query, &pagination)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("GetAPIKeyPaginateError")
+	}
+
+	childSpan.End()
+
+	return page, nil
+}
+
+func (r apiKeyRepository) GetAPIKeyByID(ctx context.Context, id int) (models.APIKey, error) {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "GetAPIKeyByIDRepository", trace.WithAttributes(attribute.String("repository", "GetAPIKeyByID")))
+		apiKey       models.APIKey
+		err          error
+	)
+
+	// Query
+	if err = database.Conn(ctx, r.db).First(&apiKey, id).Error; err != nil {
+		return apiKey, err
+	}
+
+	childSpan.End()
+
+	return apiKey, nil
+}
+
+func (r apiKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "GetAPIKeyByPrefixRepository", trace.WithAttributes(attribute.String("repository", "GetAPIKeyByPrefix")))
+		apiKey       models.APIKey
+		err          error
+	)
+
+	// Query
+	if err = database.Conn(ctx, r.db).Where(`prefix = ?`, prefix).First(&apiKey).Error; err != nil {
+		return apiKey, err
+	}
+
+	childSpan.End()
+
+	return apiKey, nil
+}
+
+func (r apiKeyRepository) CreateAPIKey(ctx context.Context, apiKey *models.APIKey) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "CreateAPIKeyRepository", trace.WithAttributes(attribute.String("repository", "CreateAPIKey")))
+		err          error
+	)
+
+	// Execute
+	if err = database.Conn(ctx, r.db).Create(apiKey).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
+
+func (r apiKeyRepository) UpdateAPIKey(ctx context.Context, id int, apiKey *models.APIKey) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "UpdateAPIKeyRepository", trace.WithAttributes(attribute.String("repository", "UpdateAPIKey")))
+		existAPIKey  models.APIKey
+		err          error
+	)
+
+	// Get model
+	if err = database.Conn(ctx, r.db).First(&existAPIKey, id).Error; err != nil {
+		return err
+	}
+
+	// Set attributes
+	existAPIKey.Name = apiKey.Name
+	existAPIKey.Scopes = apiKey.Scopes
+	existAPIKey.RateLimit = apiKey.RateLimit
+	existAPIKey.ExpiresAt = apiKey.ExpiresAt
+
+	// Execute
+	if err = database.Conn(ctx, r.db).Save(&existAPIKey).Error; err != nil {
+		return err
+	}
+
+	*apiKey = existAPIKey
+
+	childSpan.End()
+
+	return nil
+}
+
+func (r apiKeyRepository) TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "TouchAPIKeyRepository", trace.WithAttributes(attribute.String("repository", "TouchAPIKey")))
+		err          error
+	)
+
+	// Execute without touching updated_at
+	if err = database.Conn(ctx, r.db).Model(&models.APIKey{}).Where(`id = ?`, id).UpdateColumn("last_used_at", usedAt).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
+
+func (r apiKeyRepository) RevokeAPIKey(ctx context.Context, id int, revokedAt time.Time) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "RevokeAPIKeyRepository", trace.WithAttributes(attribute.String("repository", "RevokeAPIKey")))
+		err          error
+	)
+
+	// Execute
+	if err = database.Conn(ctx, r.db).Model(&models.APIKey{}).Where(`id = ? AND revoked_at IS NULL`, id).Update("revoked_at", revokedAt).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+)
+
+type (
+	// AuditLogRepository only reads, the database writes the audit log for every change
+	AuditLogRepository interface {
+		GetAuditLogPaginate(ctx context.Context, pagination database.Pagination) (*database.Page[models.AuditLog], error)
+	}
+)
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return auditLogRepository{db: db}
+}
+
+// auditLogQuery lists the columns clients may sort, filter and select audit logs by
+var auditLogQuery = database.QueryAllowlist{
+	Sortable: []string{"id", "entity", "action", "created_at"},
+	Filterable: map[string][]string{
+		"action":     {database.OperatorEq, database.OperatorIn},
+		"entity":     {database.OperatorEq, database.OperatorIn},
+		"entity_id":  {database.OperatorEq, database.OperatorIn},
+		"actor_type": {database.OperatorEq},
+		"actor_id":   {database.OperatorEq},
+		"request_id": {database.OperatorEq},
+		"ip":         {database.OperatorEq},
+		"created_at": {database.OperatorGt, database.OperatorGte, database.OperatorLt, database.OperatorLte},
+	},
+	Selectable: []string{"id", "action", "entity", "entity_id", "actor_type", "actor_id", "request_id", "ip", "changes", "created_at"},
+}
+
+func (r auditLogRepository) GetAuditLogPaginate(ctx context.Context, pagination database.Pagination) (*database.Page[models.AuditLog], error) {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "GetAuditLogPaginateRepository", trace.WithAttributes(attribute.String("repository", "GetAuditLogPaginate")))
+		err          error
+	)
+
+	if err = pagination.Validate(auditLogQuery); err != nil {
+		return nil, err
+	}
+
+	// Pagination query
+	query := database.Conn(ctx, r.db).Model(&models.AuditLog{})
+
+	page, err := database.Paginate[models.AuditLog](query, &pagination)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("GetAuditLogPaginateError")
+	}
+
+	childSpan.End()
+
+	return page, nil
+}
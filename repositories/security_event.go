@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+)
+
+type (
+	SecurityEventRepository interface {
+		GetSecurityEventPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Page[models.SecurityEvent], error)
+		CreateSecurityEvent(ctx context.Context, securityEvent *models.SecurityEvent) error
+	}
+)
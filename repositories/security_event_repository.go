@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type securityEventRepository struct {
+	db *gorm.DB
+}
+
+func NewSecurityEventRepository(db *gorm.DB) SecurityEventRepository {
+	return securityEventRepository{db: db}
+}
+
+// securityEventQuery lists the columns clients may sort, filter and select security events by
+var securityEventQuery = database.QueryAllowlist{
+	Sortable: []string{"id", "event", "created_at"},
+	Filterable: map[string][]string{
+		"event":      {database.OperatorEq, database.OperatorIn},
+		"user_id":    {database.OperatorEq, database.OperatorNull},
+		"email":      {database.OperatorEq, database.OperatorLike},
+		"ip":         {database.OperatorEq},
+		"actor":      {database.OperatorEq},
+		"created_at": {database.OperatorGt, database.OperatorGte, database.OperatorLt, database.OperatorLte},
+	},
+	Selectable: []string{"id", "event", "user_id", "email", "ip", "actor", "created_at"},
+}
+
+func (r securityEventRepository) GetSecurityEventPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Page[models.SecurityEvent], error) {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "GetSecurityEventPaginateRepository", trace.WithAttributes(attribute.String("repository", "GetSecurityEventPaginate"), attribute.String("search", search)))
+		err          error
+	)
+
+	if err = pagination.Validate(securityEventQuery); err != nil {
+		return nil, err
+	}
+
+	// Pagination query, search matches the email, IP or event exactly
+	query := database.Conn(ctx, r.db).Model(&models.SecurityEvent{})
+	if search != "" {
+		query = query.Where(`email = ? OR ip = ? OR event = ?`, search, search, search)
+	}
+
+	page, err := database.Paginate[models.SecurityEvent](query, &pagination)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("GetSecurityEventPaginateError")
+	}
+
+	childSpan.End()
+
+	return page, nil
+}
+
+func (r securityEventRepository) CreateSecurityEvent(ctx context.Context, securityEvent *models.SecurityEvent) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "CreateSecurityEventRepository", trace.WithAttributes(attribute.String("repository", "CreateSecurityEvent")))
+		err          error
+	)
+
+	// Execute
+	if err = database.Conn(ctx, r.db).Create(securityEvent).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
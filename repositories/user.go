@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
@@ -9,10 +10,14 @@ import (
 
 type (
 	UserRepository interface {
-		GetUserPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Pagination, error)
+		GetUserPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Page[models.User], error)
 		GetUserByID(ctx context.Context, id int) (models.User, error)
+		GetUserByEmail(ctx context.Context, email string) (models.User, error)
 		CreateUser(ctx context.Context, user *models.User) error
 		UpdateUser(ctx context.Context, id int, user *models.User) error
 		DeleteUser(ctx context.Context, id int) error
+		UpdateUserMFA(ctx context.Context, id int, enabled bool, secret string, enabledAt *time.Time) error
+		UpdateUserPassword(ctx context.Context, id int, passwordHash string) error
+		MarkUserEmailVerified(ctx context.Context, id int, verifiedAt time.Time) error
 	}
 )
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+)
+
+type (
+	UserRecoveryCodeRepository interface {
+		// ReplaceRecoveryCodes removes every code of the user and stores the new hashes
+		ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+		// UseRecoveryCode marks an unused code as used, it reports false if there was none
+		UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error)
+		DeleteRecoveryCodes(ctx context.Context, userID uint) error
+	}
+)
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type userRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRecoveryCodeRepository(db *gorm.DB) UserRecoveryCodeRepository {
+	return userRecoveryCodeRepository{db: db}
+}
+
+func (r userRecoveryCodeRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "ReplaceRecoveryCodesRepository", trace.WithAttributes(attribute.String("repository", "ReplaceRecoveryCodes")))
+		codes        = make([]models.UserRecoveryCode, 0, len(codeHashes))
+		err          error
+	)
+
+	for _, codeHash := range codeHashes {
+		codes = append(codes, models.UserRecoveryCode{UserID: userID, CodeHash: codeHash})
+	}
+
+	// Execute
+	err = database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(`user_id = ?`, userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
+
+func (r userRecoveryCodeRepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "UseRecoveryCodeRepository", trace.WithAttributes(attribute.String("repository", "UseRecoveryCode")))
+		result       *gorm.DB
+	)
+
+	// Execute, the used_at condition makes concurrent use of the same code safe
+	result = database.Conn(ctx, r.db).Model(&models.UserRecoveryCode{}).
+		Where(`user_id = ? AND code_hash = ? AND used_at IS NULL`, userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	childSpan.End()
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r userRecoveryCodeRepository) DeleteRecoveryCodes(ctx context.Context, userID uint) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "DeleteRecoveryCodesRepository", trace.WithAttributes(attribute.String("repository", "DeleteRecoveryCodes")))
+		err          error
+	)
+
+	// Execute
+	if err = database.Conn(ctx, r.db).Where(`user_id = ?`, userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
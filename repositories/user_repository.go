@@ -3,15 +3,18 @@ package repositories
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepository struct {
@@ -22,36 +25,77 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return userRepository{db: db}
 }
 
-func (r userRepository) GetUserPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Pagination, error) {
+// userSearchText is the text of a user indexed for trigram search, see the search migration
+const userSearchText = `(first_name || ' ' || last_name || ' ' || email)`
+
+// userQuery lists the columns clients may sort, filter and select users by
+var userQuery = database.QueryAllowlist{
+	Sortable: []string{"id", "first_name", "last_name", "email", "role", "created_at", "updated_at"},
+	Filterable: map[string][]string{
+		"id":                {database.OperatorEq, database.OperatorIn},
+		"first_name":        {database.OperatorEq, database.OperatorLike},
+		"last_name":         {database.OperatorEq, database.OperatorLike},
+		"email":             {database.OperatorEq, database.OperatorLike},
+		"phone":             {database.OperatorEq},
+		"member_code":       {database.OperatorEq},
+		"role":              {database.OperatorEq, database.OperatorNe, database.OperatorIn},
+		"mfa_enabled":       {database.OperatorEq},
+		"email_verified_at": {database.OperatorNull, database.OperatorGte, database.OperatorLte},
+		"created_at":        {database.OperatorGt, database.OperatorGte, database.OperatorLt, database.OperatorLte},
+	},
+	Selectable: []string{"id", "first_name", "last_name", "email", "phone", "member_code", "email_verified_at", "role", "mfa_enabled", "created_at", "updated_at"},
+}
+
+func (r userRepository) GetUserPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Page[models.User], error) {
 	var (
 		_, childSpan = tracing.Tracer.Start(ctx, "GetUserPaginateRepository", trace.WithAttributes(attribute.String("repository", "GetUserPaginate"), attribute.String("search", search)))
-		users        []models.User
 		err          error
 	)
 
+	if err = pagination.Validate(userQuery); err != nil {
+		return nil, err
+	}
+
 	// Pagination query
-	if search != "" {
-		if err = r.db.Scopes(database.Paginate(users, &pagination, r.db)).
-			Where(`email LIKE ?`, fmt.Sprintf(`%%%s%%`, search)).
-			Or(`first_name LIKE ?`, fmt.Sprintf(`%%%s%%`, search)).
-			Or(`last_name LIKE ?`, fmt.Sprintf(`%%%s%%`, search)).
-			Find(&users).Error; err != nil {
-			log.Println(err)
-			return nil, errors.New("GetUserPaginateError")
-		}
-	} else {
-		if err = r.db.Scopes(database.Paginate(users, &pagination, r.db)).
-			Find(&users).Error; err != nil {
-			return nil, err
-		}
+	query := database.Conn(ctx, r.db).Model(&models.User{})
+	if search = strings.TrimSpace(search); search != "" {
+		query = query.Where(r.userSearch(search))
+		pagination.RankBy(userSearchRank(search))
 	}
 
-	// Set data
-	pagination.Data = users
+	page, err := database.Paginate[models.User](query, &pagination)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("GetUserPaginateError")
+	}
 
 	childSpan.End()
 
-	return &pagination, nil
+	return page, nil
+}
+
+// userSearch matches whole words of the names and email by full-text search,
+// and any part of them by trigrams, which also covers Thai text without spaces.
+// Phone numbers and member codes only match exactly.
+func (r userRepository) userSearch(search string) *gorm.DB {
+	condition := r.db.Where(`search_vector @@ plainto_tsquery('simple', ?)`, search).
+		Or(userSearchText+` ILIKE ?`, database.ContainsPattern(search)).
+		Or(`member_code = ?`, strings.ToUpper(search))
+	if phone := utils.Digits(search); phone != "" {
+		condition = condition.Or(`phone = ?`, phone)
+	}
+
+	return condition
+}
+
+// userSearchRank puts exact matches first, then full-text and trigram matches by relevance
+func userSearchRank(search string) clause.Expr {
+	return clause.Expr{
+		SQL: `(member_code = ? OR phone = ?) DESC, ` +
+			`ts_rank(search_vector, plainto_tsquery('simple', ?)) DESC, ` +
+			`similarity(` + userSearchText + `, ?) DESC`,
+		Vars: []interface{}{strings.ToUpper(search), utils.Digits(search), search, search},
+	}
 }
 
 func (r userRepository) GetUserByID(ctx context.Context, id int) (models.User, error) {
@@ -62,7 +106,24 @@ func (r userRepository) GetUserByID(ctx context.Context, id int) (models.User, e
 	)
 
 	// Query
-	if err = r.db.First(&user, id).Error; err != nil {
+	if err = database.Conn(ctx, r.db).First(&user, id).Error; err != nil {
+		return user, err
+	}
+
+	childSpan.End()
+
+	return user, nil
+}
+
+func (r userRepository) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "GetUserByEmailRepository", trace.WithAttributes(attribute.String("repository", "GetUserByEmail")))
+		user         models.User
+		err          error
+	)
+
+	// Query
+	if err = database.Conn(ctx, r.db).Where(`email = ?`, email).First(&user).Error; err != nil {
 		return user, err
 	}
 
@@ -78,7 +139,7 @@ func (r userRepository) CreateUser(ctx context.Context, user *models.User) error
 	)
 
 	// Execute
-	if err = r.db.Create(&user).Error; err != nil {
+	if err = database.Conn(ctx, r.db).Create(&user).Error; err != nil {
 		return err
 	}
 
@@ -90,20 +151,24 @@ func (r userRepository) CreateUser(ctx context.Context, user *models.User) error
 func (r userRepository) UpdateUser(ctx context.Context, id int, user *models.User) error {
 	var (
 		_, childSpan = tracing.Tracer.Start(ctx, "UpdateUserRepository", trace.WithAttributes(attribute.String("repository", "UpdateUser")))
-		existUser    *models.User
+		existUser    models.User
 		err          error
 	)
 
 	// Get model
-	r.db.First(&existUser)
+	if err = database.Conn(ctx, r.db).First(&existUser, id).Error; err != nil {
+		return err
+	}
 
 	// Set attributes
 	existUser.FirstName = user.FirstName
 	existUser.LastName = user.LastName
 	existUser.Email = user.Email
+	existUser.Phone = user.Phone
+	existUser.MemberCode = user.MemberCode
 
 	// Execute
-	if err = r.db.Save(&existUser).Error; err != nil {
+	if err = database.Conn(ctx, r.db).Save(&existUser).Error; err != nil {
 		return err
 	}
 
@@ -119,7 +184,57 @@ func (r userRepository) DeleteUser(ctx context.Context, id int) error {
 	)
 
 	// Execute
-	if err = r.db.Delete(&models.User{}, id).Error; err != nil {
+	if err = database.Conn(ctx, r.db).Delete(&models.User{}, id).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
+
+func (r userRepository) UpdateUserMFA(ctx context.Context, id int, enabled bool, secret string, enabledAt *time.Time) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "UpdateUserMFARepository", trace.WithAttributes(attribute.String("repository", "UpdateUserMFA")))
+		err          error
+	)
+
+	// Execute, Select is required to write false and empty values
+	if err = database.Conn(ctx, r.db).Model(&models.User{}).Where(`id = ?`, id).
+		Select("mfa_enabled", "mfa_secret", "mfa_enabled_at").
+		Updates(models.User{MFAEnabled: enabled, MFASecret: secret, MFAEnabledAt: enabledAt}).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
+
+func (r userRepository) UpdateUserPassword(ctx context.Context, id int, passwordHash string) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "UpdateUserPasswordRepository", trace.WithAttributes(attribute.String("repository", "UpdateUserPassword")))
+		err          error
+	)
+
+	// Execute
+	if err = database.Conn(ctx, r.db).Model(&models.User{}).Where(`id = ?`, id).Update("password_hash", passwordHash).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
+
+func (r userRepository) MarkUserEmailVerified(ctx context.Context, id int, verifiedAt time.Time) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "MarkUserEmailVerifiedRepository", trace.WithAttributes(attribute.String("repository", "MarkUserEmailVerified")))
+		err          error
+	)
+
+	// Execute, keep the first verification time
+	if err = database.Conn(ctx, r.db).Model(&models.User{}).Where(`id = ? AND email_verified_at IS NULL`, id).Update("email_verified_at", verifiedAt).Error; err != nil {
 		return err
 	}
 
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+)
+
+type (
+	UserTokenRepository interface {
+		CreateUserToken(ctx context.Context, userToken *models.UserToken) error
+		// ConsumeUserToken marks an unused, unexpired token as used and returns it
+		ConsumeUserToken(ctx context.Context, purpose string, tokenHash string, now time.Time) (models.UserToken, error)
+		// InvalidateUserTokens marks every outstanding token of the purpose as used
+		InvalidateUserTokens(ctx context.Context, userID uint, purpose string, now time.Time) error
+	}
+)
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return userTokenRepository{db: db}
+}
+
+func (r userTokenRepository) CreateUserToken(ctx context.Context, userToken *models.UserToken) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "CreateUserTokenRepository", trace.WithAttributes(attribute.String("repository", "CreateUserToken")))
+		err          error
+	)
+
+	// Execute
+	if err = database.Conn(ctx, r.db).Create(userToken).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
+
+func (r userTokenRepository) ConsumeUserToken(ctx context.Context, purpose string, tokenHash string, now time.Time) (models.UserToken, error) {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "ConsumeUserTokenRepository", trace.WithAttributes(attribute.String("repository", "ConsumeUserToken")))
+		userToken    models.UserToken
+		result       *gorm.DB
+	)
+
+	// Execute, a single conditional update makes the token single-use under concurrency
+	result = database.Conn(ctx, r.db).Model(&userToken).
+		Clauses(clause.Returning{}).
+		Where(`purpose = ? AND token_hash = ? AND used_at IS NULL AND expires_at > ?`, purpose, tokenHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return userToken, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return userToken, gorm.ErrRecordNotFound
+	}
+
+	childSpan.End()
+
+	return userToken, nil
+}
+
+func (r userTokenRepository) InvalidateUserTokens(ctx context.Context, userID uint, purpose string, now time.Time) error {
+	var (
+		_, childSpan = tracing.Tracer.Start(ctx, "InvalidateUserTokensRepository", trace.WithAttributes(attribute.String("repository", "InvalidateUserTokens")))
+		err          error
+	)
+
+	// Execute
+	if err = database.Conn(ctx, r.db).Model(&models.UserToken{}).
+		Where(`user_id = ? AND purpose = ? AND used_at IS NULL`, userID, purpose).
+		Update("used_at", now).Error; err != nil {
+		return err
+	}
+
+	childSpan.End()
+
+	return nil
+}
@@ -1,18 +1,22 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/handlers"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/microservices"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/mail"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/repositories"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/services"
+	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -24,7 +28,6 @@ func HTTPRootMiddleware(ms *microservices.Microservice) {
 	fiberRequestID := requestid.New()
 	fiberLogger := logger.New(config.LoggerConfig)
 	fiberRecover := recover.New()
-	fiberLimiter := limiter.New(config.LimiterConfig)
 	fiberETag := etag.New(config.ETagConfig)
 	fiberCors := cors.New(config.CorsConfig)
 	fiberFavicon := favicon.New(config.FaviconConfig)
@@ -32,7 +35,6 @@ func HTTPRootMiddleware(ms *microservices.Microservice) {
 	ms.Use(fiberRequestID)
 	ms.Use(fiberLogger)
 	ms.Use(fiberRecover)
-	ms.Use(fiberLimiter)
 	ms.Use(fiberETag)
 	ms.Use(fiberCors)
 	ms.Use(fiberFavicon)
@@ -50,17 +52,40 @@ func HTTPRootRoute(ms *microservices.Microservice) {
 	ms.GET("/monitor", monitor.New(monitor.Config{Title: "Fiber"}))
 }
 
-func HTTPRoutes(ms *microservices.Microservice) {
+func HTTPRoutes(ms *microservices.Microservice, redisClient redis.UniversalClient, cacher *cache.Cache) {
 	// Initialize repositories, services, and handlers
 	userRepo := repositories.NewUserRepository(database.DBConn)
+	apiKeyRepo := repositories.NewAPIKeyRepository(database.DBConn)
+	userRecoveryCodeRepo := repositories.NewUserRecoveryCodeRepository(database.DBConn)
+	userTokenRepo := repositories.NewUserTokenRepository(database.DBConn)
+	securityEventRepo := repositories.NewSecurityEventRepository(database.DBConn)
+	auditLogRepo := repositories.NewAuditLogRepository(database.DBConn)
+
+	// Services run several repository calls atomically through the transactor
+	transactor := database.NewTransactor(database.DBConn)
 
 	// Initialize services
 	userService := services.NewUserService(userRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, redisClient, cacher)
+	sessionService := services.NewSessionService(redisClient)
+	mfaService := services.NewMFAService(userRepo, userRecoveryCodeRepo, transactor, redisClient)
+	loginGuardService := services.NewLoginGuardService(userRepo, securityEventRepo, redisClient)
+	authService := services.NewAuthService(userRepo, mfaService, loginGuardService, redisClient)
+	accountService := services.NewAccountService(userRepo, userTokenRepo, transactor, sessionService, mail.NewSender(), redisClient)
+	rateLimiterService := services.NewRateLimiterService(redisClient)
+	auditLogService := services.NewAuditLogService(auditLogRepo)
 
 	// Initialize handlers
 	handler := handlers.NewHandler(
-		cache.Cacher,
+		cacher,
 		userService,
+		apiKeyService,
+		sessionService,
+		authService,
+		mfaService,
+		accountService,
+		loginGuardService,
+		auditLogService,
 	)
 
 	// REST API endpoint ------------------------------------------------------------------
@@ -69,10 +94,86 @@ func HTTPRoutes(ms *microservices.Microservice) {
 	api := ms.Group("api")
 	apiV1 := api.Group("v1")
 
-	// User service routes
-	apiV1.Get("/users", func(c *fiber.Ctx) error { return handler.GetUsers(c) })
+	// Rate limits are shared by all instances, users and API keys get their own budget
+	rateLimitWindow := time.Duration(config.AppConfig.RateLimitWindowSeconds) * time.Second
+	apiV1.Use(middlewares.Identify(apiKeyService, sessionService))
+	apiV1.Use(middlewares.RateLimit(rateLimiterService, middlewares.RateLimitPolicy{
+		Name:           "api",
+		Limit:          config.AppConfig.RateLimit,
+		PrincipalLimit: config.AppConfig.RateLimitPrincipal,
+		Window:         rateLimitWindow,
+	}))
+
+	// Changes are audited with the principal, request ID and IP of the request
+	apiV1.Use(middlewares.Audit())
+
+	// Callers read from the primary database for a while after they wrote
+	if len(config.AppConfig.DatabaseReplicaDSNs) > 0 {
+		apiV1.Use(middlewares.ReadYourWrites(redisClient, time.Duration(config.AppConfig.DatabaseReadYourWritesSeconds)*time.Second))
+	}
+
+	// Retries of POST and PATCH requests with an Idempotency-Key replay the first response,
+	// except for the routes answering with secrets
+	apiV1.Use(middlewares.Idempotency(cacher, time.Duration(config.AppConfig.IdempotencyTTLHours)*time.Hour))
+	secretResponse := middlewares.SecretResponse()
+
+	// User service routes, user writes flush the cached list responses with the users tag.
+	// A user's detail is cached by the handler under its entity tag instead.
+	apiV1.Get("/users", middlewares.ResponseCache(cacher, handlers.UsersCacheTag), func(c *fiber.Ctx) error { return handler.GetUsers(c) })
 	apiV1.Get("/users/:id", func(c *fiber.Ctx) error { return handler.GetUser(c) })
 	apiV1.Post("/users", func(c *fiber.Ctx) error { return handler.CreateUser(c) })
 	apiV1.Put("/users/:id", func(c *fiber.Ctx) error { return handler.UpdateUser(c) })
 	apiV1.Delete("/users/:id", func(c *fiber.Ctx) error { return handler.DeleteUser(c) })
+
+	// Auth routes
+	auth := apiV1.Group("auth", middlewares.RateLimit(rateLimiterService, middlewares.RateLimitPolicy{
+		Name:           "auth",
+		Limit:          config.AppConfig.RateLimitAuth,
+		PrincipalLimit: config.AppConfig.RateLimitAuth,
+		Window:         rateLimitWindow,
+	}))
+	auth.Post("/login", secretResponse, func(c *fiber.Ctx) error { return handler.Login(c) })
+	auth.Post("/login/mfa", secretResponse, func(c *fiber.Ctx) error { return handler.LoginMFA(c) })
+	auth.Post("/email/verify", func(c *fiber.Ctx) error { return handler.VerifyEmail(c) })
+	auth.Post("/password/forgot", func(c *fiber.Ctx) error { return handler.ForgotPassword(c) })
+	auth.Post("/password/reset", func(c *fiber.Ctx) error { return handler.ResetPassword(c) })
+
+	// Authenticated user routes
+	me := apiV1.Group("me", middlewares.AuthProtected(sessionService))
+	me.Get("/sessions", func(c *fiber.Ctx) error { return handler.GetMySessions(c) })
+	me.Delete("/sessions", func(c *fiber.Ctx) error { return handler.RevokeMyOtherSessions(c) })
+	me.Delete("/sessions/:id", func(c *fiber.Ctx) error { return handler.RevokeMySession(c) })
+	me.Post("/email/verification", func(c *fiber.Ctx) error { return handler.RequestEmailVerification(c) })
+	me.Post("/mfa/totp", secretResponse, func(c *fiber.Ctx) error { return handler.EnrollTOTP(c) })
+	me.Post("/mfa/totp/confirm", secretResponse, func(c *fiber.Ctx) error { return handler.ConfirmTOTP(c) })
+	me.Post("/mfa/recovery-codes", secretResponse, func(c *fiber.Ctx) error { return handler.RegenerateRecoveryCodes(c) })
+	me.Post("/mfa/disable", func(c *fiber.Ctx) error { return handler.DisableMFA(c) })
+
+	// Admin routes
+	admin := apiV1.Group("admin", middlewares.AuthProtected(sessionService), middlewares.RequireRoles(middlewares.RoleAdmin))
+
+	// Session management routes
+	admin.Post("/users/:id/sign-out", func(c *fiber.Ctx) error { return handler.SignOutUserEverywhere(c) })
+
+	// Login protection routes
+	admin.Get("/security-events", func(c *fiber.Ctx) error { return handler.GetSecurityEvents(c) })
+	admin.Delete("/users/:id/lockout", func(c *fiber.Ctx) error { return handler.UnlockUser(c) })
+
+	// Audit trail routes
+	admin.Get("/audit-logs", func(c *fiber.Ctx) error { return handler.GetAuditLogs(c) })
+
+	// API key management routes
+	admin.Get("/api-keys", func(c *fiber.Ctx) error { return handler.GetAPIKeys(c) })
+	admin.Get("/api-keys/:id", func(c *fiber.Ctx) error { return handler.GetAPIKey(c) })
+	admin.Post("/api-keys", secretResponse, func(c *fiber.Ctx) error { return handler.CreateAPIKey(c) })
+	admin.Put("/api-keys/:id", func(c *fiber.Ctx) error { return handler.UpdateAPIKey(c) })
+	admin.Post("/api-keys/:id/rotate", secretResponse, func(c *fiber.Ctx) error { return handler.RotateAPIKey(c) })
+	admin.Delete("/api-keys/:id", func(c *fiber.Ctx) error { return handler.RevokeAPIKey(c) })
+
+	// Cache administration routes
+	admin.Get("/cache/stats", func(c *fiber.Ctx) error { return handler.GetCacheStats(c) })
+	admin.Get("/cache/tags", func(c *fiber.Ctx) error { return handler.GetCacheTags(c) })
+	admin.Delete("/cache/tags/:tag", func(c *fiber.Ctx) error { return handler.FlushCacheTag(c) })
+	admin.Get("/cache/keys", func(c *fiber.Ctx) error { return handler.GetCacheKey(c) })
+	admin.Delete("/cache/keys", func(c *fiber.Ctx) error { return handler.PurgeCacheKeys(c) })
 }
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+type (
+	AccountService interface {
+		// RequestEmailVerification emails a verification link to the user
+		RequestEmailVerification(ctx context.Context, userID int, ip string) error
+		// VerifyEmail marks the email of the token's user verified and returns the user ID
+		VerifyEmail(ctx context.Context, token string) (int, error)
+		// RequestPasswordReset emails a reset link, it does not reveal whether the email is registered
+		RequestPasswordReset(ctx context.Context, email string, ip string) error
+		// ResetPassword sets the new password, signs the user out everywhere and returns the user ID
+		ResetPassword(ctx context.Context, token string, password string) (int, error)
+	}
+	VerifyEmailDto struct {
+		Token string `json:"token" form:"token" validate:"required,max=500"`
+	}
+	ForgotPasswordDto struct {
+		Email string `json:"email" form:"email" validate:"required,email,max=100"`
+	}
+	ResetPasswordDto struct {
+		Token    string `json:"token" form:"token" validate:"required,max=500"`
+		Password string `json:"password" form:"password" validate:"required,min=8,max=72"`
+	}
+)
+
+var (
+	ErrInvalidUserToken     = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrTooManyRequests      = errors.New("too many requests, try again later")
+)
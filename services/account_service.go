@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/mail"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/repositories"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const accountRateWindow = time.Hour
+
+type (
+	accountService struct {
+		userRepository      repositories.UserRepository
+		userTokenRepository repositories.UserTokenRepository
+		transactor          database.Transactor
+		sessionService      SessionService
+		mailSender          mail.Sender
+		redis               redis.UniversalClient
+	}
+)
+
+func NewAccountService(
+	userRepo repositories.UserRepository,
+	userTokenRepo repositories.UserTokenRepository,
+	transactor database.Transactor,
+	sessionService SessionService,
+	mailSender mail.Sender,
+	redisClient redis.UniversalClient,
+) AccountService {
+	return &accountService{
+		userRepository:      userRepo,
+		userTokenRepository: userTokenRepo,
+		transactor:          transactor,
+		sessionService:      sessionService,
+		mailSender:          mailSender,
+		redis:               redisClient,
+	}
+}
+
+func (s accountService) RequestEmailVerification(ctx context.Context, userID int, ip string) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RequestEmailVerificationService", trace.WithAttributes(attribute.String("service", "RequestEmailVerification")))
+	defer childSpan.End()
+
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	if err = s.allow(ctx, user.Email, ip); err != nil {
+		return err
+	}
+
+	ttl := time.Duration(config.AppConfig.EmailVerificationTTLMinutes) * time.Minute
+	token, err := s.issueUserToken(ctx, user, models.UserTokenPurposeEmailVerification, ttl)
+	if err != nil {
+		return err
+	}
+
+	return s.mailSender.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nPlease verify your email address by opening the link below:\n\n%s\n\nThe link expires in %d minutes.\n",
+			user.FirstName,
+			config.AppConfig.AppURL+"/verify-email?token="+url.QueryEscape(token),
+			config.AppConfig.EmailVerificationTTLMinutes,
+		),
+	})
+}
+
+func (s accountService) VerifyEmail(ctx context.Context, token string) (int, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "VerifyEmailService", trace.WithAttributes(attribute.String("service", "VerifyEmail")))
+	defer childSpan.End()
+
+	userToken, err := s.consumeUserToken(ctx, token, models.UserTokenPurposeEmailVerification)
+	if err != nil {
+		return 0, err
+	}
+
+	userID := int(userToken.UserID)
+	return userID, s.userRepository.MarkUserEmailVerified(ctx, userID, time.Now())
+}
+
+func (s accountService) RequestPasswordReset(ctx context.Context, email string, ip string) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RequestPasswordResetService", trace.WithAttributes(attribute.String("service", "RequestPasswordReset")))
+	defer childSpan.End()
+
+	// Limits apply to unknown emails too, so they can not be used to probe for accounts
+	if err := s.allow(ctx, email, ip); err != nil {
+		return err
+	}
+
+	user, err := s.userRepository.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	ttl := time.Duration(config.AppConfig.PasswordResetTTLMinutes) * time.Minute
+	token, err := s.issueUserToken(ctx, user, models.UserTokenPurposePasswordReset, ttl)
+	if err != nil {
+		return err
+	}
+
+	return s.mailSender.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %d minutes. If you did not request this, you can ignore this email.\n",
+			user.FirstName,
+			config.AppConfig.AppURL+"/reset-password?token="+url.QueryEscape(token),
+			config.AppConfig.PasswordResetTTLMinutes,
+		),
+	})
+}
+
+func (s accountService) ResetPassword(ctx context.Context, token string, password string) (int, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "ResetPasswordService", trace.WithAttributes(attribute.String("service", "ResetPassword")))
+	defer childSpan.End()
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	// The token is only used up when the password changes
+	var userID int
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		userToken, err := s.consumeUserToken(ctx, token, models.UserTokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		userID = int(userToken.UserID)
+		if err = s.userRepository.UpdateUserPassword(ctx, userID, string(passwordHash)); err != nil {
+			return err
+		}
+
+		// Other reset links are void, and the reset link proves the email belongs to the user
+		if err = s.userTokenRepository.InvalidateUserTokens(ctx, userToken.UserID, models.UserTokenPurposePasswordReset, now); err != nil {
+			return err
+		}
+		return s.userRepository.MarkUserEmailVerified(ctx, userID, now)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, s.sessionService.RevokeAllSessions(ctx, strconv.Itoa(userID))
+}
+
+// allow applies the per email and per IP limits of account emails
+func (s accountService) allow(ctx context.Context, email string, ip string) error {
+	count, _, err := incrementWindow(ctx, s.redis, RedisKey("account", "email", strings.ToLower(email)), accountRateWindow)
+	if err != nil {
+		return err
+	}
+	if count > config.AppConfig.AccountEmailRateLimit {
+		return ErrTooManyRequests
+	}
+
+	count, _, err = incrementWindow(ctx, s.redis, RedisKey("account", "ip", ip), accountRateWindow)
+	if err != nil {
+		return err
+	}
+	if count > config.AppConfig.AccountIPRateLimit {
+		return ErrTooManyRequests
+	}
+
+	return nil
+}
+
+// issueUserToken creates a signed token and stores its hash, earlier tokens of the purpose are voided.
+// The token is <payload>.<signature>, the payload is purpose.user_id.expires_at.nonce
+func (s accountService) issueUserToken(ctx context.Context, user models.User, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	payload := fmt.Sprintf("%s.%d.%d.%s", purpose, user.ID, expiresAt.Unix(), hex.EncodeToString(nonce))
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(signUserToken(payload))
+
+	if err := s.userTokenRepository.InvalidateUserTokens(ctx, user.ID, purpose, now); err != nil {
+		return "", err
+	}
+
+	err := s.userTokenRepository.CreateUserToken(ctx, &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashUserToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeUserToken checks the signature and expiry before using the token up in the database
+func (s accountService) consumeUserToken(ctx context.Context, token string, purpose string) (models.UserToken, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return models.UserToken{}, ErrInvalidUserToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return models.UserToken{}, ErrInvalidUserToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, signUserToken(string(payload))) {
+		return models.UserToken{}, ErrInvalidUserToken
+	}
+
+	fields := strings.Split(string(payload), ".")
+	if len(fields) != 4 || fields[0] != purpose {
+		return models.UserToken{}, ErrInvalidUserToken
+	}
+	expiresAt, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || time.Now().Unix() >= expiresAt {
+		return models.UserToken{}, ErrInvalidUserToken
+	}
+
+	userToken, err := s.userTokenRepository.ConsumeUserToken(ctx, purpose, hashUserToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return userToken, ErrInvalidUserToken
+		}
+		return userToken, err
+	}
+
+	return userToken, nil
+}
+
+func signUserToken(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.AppKey))
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
+
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
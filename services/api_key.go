@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+)
+
+type (
+	APIKeyService interface {
+		GetAPIKeys(ctx context.Context, paginate database.Pagination, search string) (*database.Page[models.APIKey], error)
+		GetAPIKey(ctx context.Context, id int) (map[string]interface{}, error)
+		CreateAPIKey(ctx context.Context, apiKeyDto *APIKeyDto) (*IssuedAPIKey, error)
+		UpdateAPIKey(ctx context.Context, id int, apiKeyDto *APIKeyDto) error
+		RotateAPIKey(ctx context.Context, id int) (*IssuedAPIKey, error)
+		RevokeAPIKey(ctx context.Context, id int) error
+		// Authenticate resolves a raw key sent by a client to an active API key
+		Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error)
+		// Allow consumes one request from the per-key rate limit
+		Allow(ctx context.Context, apiKey *models.APIKey) (*RateLimitResult, error)
+	}
+	APIKeyDto struct {
+		Name      string     `json:"name" form:"name" validate:"required,max=100"`
+		Scopes    []string   `json:"scopes" form:"scopes" validate:"dive,required,max=100"`
+		RateLimit int        `json:"rate_limit" form:"rate_limit" validate:"min=0"`
+		ExpiresAt *time.Time `json:"expires_at" form:"expires_at"`
+	}
+	// IssuedAPIKey carries the plain text key, it is only returned once on create or rotate
+	IssuedAPIKey struct {
+		models.APIKey
+		Key string `json:"key"`
+	}
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrAPIKeyRevoked  = errors.New("api key has been revoked")
+	ErrAPIKeyExpired  = errors.New("api key has expired")
+	ErrAPIKeyInactive = errors.New("api key is not active")
+)
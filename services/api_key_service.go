@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/repositories"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyScheme      = "sk_"
+	apiKeyPrefixBytes = 4
+	apiKeySecretBytes = 32
+	apiKeyCacheTag    = "api_keys"
+	apiKeyRateWindow  = time.Minute
+	apiKeyTouchEvery  = time.Minute
+)
+
+type (
+	apiKeyService struct {
+		apiKeyRepository repositories.APIKeyRepository
+		redis            redis.UniversalClient
+		cacher           *cache.Cache
+	}
+)
+
+func NewAPIKeyService(
+	apiKeyRepo repositories.APIKeyRepository,
+	redisClient redis.UniversalClient,
+	cacher *cache.Cache,
+) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepository: apiKeyRepo,
+		redis:            redisClient,
+		cacher:           cacher,
+	}
+}
+
+func (s apiKeyService) GetAPIKeys(ctx context.Context, paginate database.Pagination, search string) (*database.Page[models.APIKey], error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "GetAPIKeysService", trace.WithAttributes(attribute.String("service", "GetAPIKeys")))
+	result, err := s.apiKeyRepository.GetAPIKeyPaginate(ctx, paginate, search)
+	childSpan.End()
+
+	return result, err
+}
+
+func (s apiKeyService) GetAPIKey(ctx context.Context, id int) (map[string]interface{}, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "GetAPIKeyService", trace.WithAttributes(attribute.String("service", "GetAPIKey")))
+	apiKey, err := s.apiKeyRepository.GetAPIKeyByID(ctx, id)
+	childSpan.End()
+
+	return map[string]interface{}{"data": apiKey}, err
+}
+
+func (s apiKeyService) CreateAPIKey(ctx context.Context, apiKeyDto *APIKeyDto) (*IssuedAPIKey, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "CreateAPIKeyService", trace.WithAttributes(attribute.String("service", "CreateAPIKey")))
+	defer childSpan.End()
+
+	apiKey := new(models.APIKey)
+	apiKey.Name = apiKeyDto.Name
+	apiKey.Scopes = apiKeyDto.Scopes
+	apiKey.RateLimit = apiKeyDto.RateLimit
+	apiKey.ExpiresAt = apiKeyDto.ExpiresAt
+
+	return s.issue(ctx, apiKey)
+}
+
+func (s apiKeyService) UpdateAPIKey(ctx context.Context, id int, apiKeyDto *APIKeyDto) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "UpdateAPIKeyService", trace.WithAttributes(attribute.String("service", "UpdateAPIKey")))
+	defer childSpan.End()
+
+	apiKey := new(models.APIKey)
+	apiKey.Name = apiKeyDto.Name
+	apiKey.Scopes = apiKeyDto.Scopes
+	apiKey.RateLimit = apiKeyDto.RateLimit
+	apiKey.ExpiresAt = apiKeyDto.ExpiresAt
+
+	if err := s.apiKeyRepository.UpdateAPIKey(ctx, id, apiKey); err != nil {
+		return err
+	}
+
+	// Cached key records must pick up the new scopes and limits
+	return s.cacher.Tag(apiKeyCacheTag).Flush(ctx)
+}
+
+func (s apiKeyService) RotateAPIKey(ctx context.Context, id int) (*IssuedAPIKey, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RotateAPIKeyService", trace.WithAttributes(attribute.String("service", "RotateAPIKey")))
+	defer childSpan.End()
+
+	oldKey, err := s.apiKeyRepository.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !oldKey.IsActive(time.Now()) {
+		return nil, ErrAPIKeyInactive
+	}
+
+	// The replacement inherits everything but the secret
+	newKey := &models.APIKey{
+		Name:          oldKey.Name,
+		Scopes:        oldKey.Scopes,
+		RateLimit:     oldKey.RateLimit,
+		ExpiresAt:     oldKey.ExpiresAt,
+		RotatedFromID: &oldKey.ID,
+	}
+	issued, err := s.issue(ctx, newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the old key working for a grace period so devices can switch over,
+	// without a grace period it is revoked right away
+	grace := time.Duration(config.AppConfig.APIKeyRotationGraceMinutes) * time.Minute
+	if grace <= 0 {
+		if err = s.RevokeAPIKey(ctx, id); err != nil {
+			return nil, err
+		}
+		return issued, nil
+	}
+	graceEnd := time.Now().Add(grace)
+	if oldKey.ExpiresAt == nil || oldKey.ExpiresAt.After(graceEnd) {
+		oldKey.ExpiresAt = &graceEnd
+	}
+	if err = s.apiKeyRepository.UpdateAPIKey(ctx, id, &oldKey); err != nil {
+		return nil, err
+	}
+	if err = s.cacher.Tag(apiKeyCacheTag).Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	return issued, nil
+}
+
+func (s apiKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RevokeAPIKeyService", trace.WithAttributes(attribute.String("service", "RevokeAPIKey")))
+	defer childSpan.End()
+
+	apiKey, err := s.apiKeyRepository.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// The revocation marker is checked before anything else, so the key stops working
+	// immediately. It only has to outlive the cached records, which are flushed below
+	// and expire with the cache TTL, or the key itself when it expires sooner.
+	revokedFor := time.Duration(config.AppConfig.CacheMinuteDuration) * time.Minute
+	if apiKey.ExpiresAt != nil && time.Until(*apiKey.ExpiresAt) < revokedFor {
+		revokedFor = time.Until(*apiKey.ExpiresAt)
+	}
+	if revokedFor > 0 {
+		if err = s.redis.Set(ctx, revokedAPIKeyKey(apiKey.Prefix), 1, revokedFor).Err(); err != nil {
+			return err
+		}
+	}
+
+	if err = s.apiKeyRepository.RevokeAPIKey(ctx, id, time.Now()); err != nil {
+		return err
+	}
+
+	return s.cacher.Tag(apiKeyCacheTag).Flush(ctx)
+}
+
+func (s apiKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "AuthenticateAPIKeyService", trace.WithAttributes(attribute.String("service", "AuthenticateAPIKey")))
+	defer childSpan.End()
+
+	prefix, secret, ok := parseAPIKey(rawKey)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Check the revocation marker
+	revoked, err := s.redis.Exists(ctx, revokedAPIKeyKey(prefix)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if revoked > 0 {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	// Load the key record from cache or database, the record is cached under a key
+	// derived from the secret once the secret matched, so it carries no hash
+	var record *models.APIKey
+	cacheKey := apiKeyCacheKey(prefix, secret)
+	if err = s.cacher.Get(ctx, cacheKey, &record); err != nil {
+		return nil, err
+	}
+	if record == nil {
+		apiKey, err := s.apiKeyRepository.GetAPIKeyByPrefix(ctx, prefix)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrInvalidAPIKey
+			}
+			return nil, err
+		}
+		if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(apiKey.KeyHash)) != 1 {
+			return nil, ErrInvalidAPIKey
+		}
+
+		apiKey.KeyHash = ""
+		record = &apiKey
+		if err = s.cacher.Tag(apiKeyCacheTag).Set(ctx, cacheKey, record); err != nil {
+			utils.HandleErrors(err)
+		}
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if !record.IsActive(now) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	// Track last usage at most once per interval to avoid a write on every request
+	touched, err := s.redis.SetNX(ctx, RedisKey("api_keys", "last_used", prefix), now.Unix(), apiKeyTouchEvery).Result()
+	if err != nil {
+		utils.HandleErrors(err)
+	} else if touched {
+		if err = s.apiKeyRepository.TouchAPIKey(ctx, record.ID, now); err != nil {
+			utils.HandleErrors(err)
+		}
+	}
+
+	return record, nil
+}
+
+func (s apiKeyService) Allow(ctx context.Context, apiKey *models.APIKey) (*RateLimitResult, error) {
+	limit := apiKey.RateLimit
+	if limit <= 0 {
+		limit = config.AppConfig.APIKeyRateLimit
+	}
+
+	return slidingWindow(ctx, s.redis, RedisKey("api_keys", "rate", apiKey.Prefix), limit, apiKeyRateWindow)
+}
+
+// issue generates a new secret for the key and stores the key
+func (s apiKeyService) issue(ctx context.Context, apiKey *models.APIKey) (*IssuedAPIKey, error) {
+	prefixBytes := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, err
+	}
+	secretBytes := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, err
+	}
+
+	prefix := hex.EncodeToString(prefixBytes)
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = hashAPIKeySecret(secret)
+
+	if err := s.apiKeyRepository.CreateAPIKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	return &IssuedAPIKey{
+		APIKey: *apiKey,
+		Key:    apiKeyScheme + prefix + "." + secret,
+	}, nil
+}
+
+// parseAPIKey splits a key in the form sk_<prefix>.<secret>
+func parseAPIKey(rawKey string) (prefix string, secret string, ok bool) {
+	rest, found := strings.CutPrefix(rawKey, apiKeyScheme)
+	if !found {
+		return "", "", false
+	}
+
+	prefix, secret, found = strings.Cut(rest, ".")
+	if !found || len(prefix) != apiKeyPrefixBytes*2 || secret == "" {
+		return "", "", false
+	}
+
+	return prefix, secret, true
+}
+
+// Secrets are 256 bit random values, so a fast hash is enough
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyCacheKey names the cached record of a key after a digest of its secret,
+// apart from the stored hash. Inspect and Purge refuse the private namespace.
+func apiKeyCacheKey(prefix string, secret string) string {
+	sum := sha256.Sum256([]byte("api-key-cache:" + secret))
+	return cache.PrivateNamespace + "api_key:{" + prefix + "}:" + hex.EncodeToString(sum[:])
+}
+
+func revokedAPIKeyKey(prefix string) string {
+	return RedisKey("api_keys", "revoked", prefix)
+}
+
+// RedisKey builds a key under the service prefix, outside the cache namespace
+func RedisKey(parts ...string) string {
+	return strings.Join(append([]string{config.AppConfig.RedisPrefix}, parts...), ":")
+}
@@ -0,0 +1,15 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+)
+
+type (
+	// AuditLogService reads the audit trail of the data changes
+	AuditLogService interface {
+		GetAuditLogs(ctx context.Context, paginate database.Pagination) (*database.Page[models.AuditLog], error)
+	}
+)
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/repositories"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	auditLogService struct {
+		auditLogRepository repositories.AuditLogRepository
+	}
+)
+
+func NewAuditLogService(
+	auditLogRepo repositories.AuditLogRepository,
+) AuditLogService {
+	return &auditLogService{
+		auditLogRepository: auditLogRepo,
+	}
+}
+
+func (s auditLogService) GetAuditLogs(ctx context.Context, paginate database.Pagination) (*database.Page[models.AuditLog], error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "GetAuditLogsService", trace.WithAttributes(attribute.String("service", "GetAuditLogs")))
+	result, err := s.auditLogRepository.GetAuditLogPaginate(ctx, paginate)
+	childSpan.End()
+
+	return result, err
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+type (
+	AuthService interface {
+		// Login checks the password, users with MFA get a challenge instead of an access token
+		Login(ctx context.Context, loginDto *LoginDto, ip string) (*LoginResult, error)
+		// LoginMFA exchanges a challenge and a TOTP or recovery code for an access token
+		LoginMFA(ctx context.Context, loginMFADto *LoginMFADto, ip string) (*LoginResult, error)
+	}
+	LoginDto struct {
+		Email    string `json:"email" form:"email" validate:"required,email,max=100"`
+		Password string `json:"password" form:"password" validate:"required,max=72"`
+	}
+	LoginMFADto struct {
+		MFAToken     string `json:"mfa_token" form:"mfa_token" validate:"required"`
+		Code         string `json:"code" form:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+		RecoveryCode string `json:"recovery_code" form:"recovery_code" validate:"required_without=Code,omitempty,max=20"`
+	}
+	LoginResult struct {
+		AccessToken           string `json:"access_token,omitempty"`
+		TokenType             string `json:"token_type,omitempty"`
+		ExpiresIn             int    `json:"expires_in,omitempty"`
+		Scope                 string `json:"scope,omitempty"`
+		MFARequired           bool   `json:"mfa_required,omitempty"`
+		MFAToken              string `json:"mfa_token,omitempty"`
+		MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	}
+)
+
+var ErrInvalidCredentials = errors.New("invalid email or password")
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/repositories"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// dummyPasswordHash is compared against when the user does not exist,
+// so the response time does not reveal which emails are registered
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+type (
+	authService struct {
+		userRepository    repositories.UserRepository
+		mfaService        MFAService
+		loginGuardService LoginGuardService
+		redis             redis.UniversalClient
+	}
+)
+
+func NewAuthService(
+	userRepo repositories.UserRepository,
+	mfaService MFAService,
+	loginGuardService LoginGuardService,
+	redisClient redis.UniversalClient,
+) AuthService {
+	return &authService{
+		userRepository:    userRepo,
+		mfaService:        mfaService,
+		loginGuardService: loginGuardService,
+		redis:             redisClient,
+	}
+}
+
+func (s authService) Login(ctx context.Context, loginDto *LoginDto, ip string) (*LoginResult, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "LoginService", trace.WithAttributes(attribute.String("service", "Login")))
+	defer childSpan.End()
+
+	// Locked accounts and throttled IPs are rejected before the password is checked
+	if err := s.loginGuardService.Check(ctx, loginDto.Email, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepository.GetUserByEmail(ctx, loginDto.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err != nil || user.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(loginDto.Password))
+		return nil, s.failed(ctx, loginDto.Email, ip, nil, ErrInvalidCredentials)
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(loginDto.Password)); err != nil {
+		return nil, s.failed(ctx, loginDto.Email, ip, &user.ID, ErrInvalidCredentials)
+	}
+
+	// The attempt only counts as successful once the second factor is verified
+	if user.MFAEnabled {
+		return issueMFAChallenge(user)
+	}
+
+	if err = s.loginGuardService.RecordSuccess(ctx, user.Email, ip, user.ID); err != nil {
+		return nil, err
+	}
+
+	return issueAccessToken(user, []string{AuthMethodPassword})
+}
+
+func (s authService) LoginMFA(ctx context.Context, loginMFADto *LoginMFADto, ip string) (*LoginResult, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "LoginMFAService", trace.WithAttributes(attribute.String("service", "LoginMFA")))
+	defer childSpan.End()
+
+	challenge, err := parseMFAChallenge(loginMFADto.MFAToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepository.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+
+	// Guessing codes counts against the same limits as guessing passwords
+	if err = s.loginGuardService.Check(ctx, user.Email, ip); err != nil {
+		return nil, err
+	}
+	if err = s.mfaService.VerifyCode(ctx, &user, loginMFADto.Code, loginMFADto.RecoveryCode); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			return nil, s.failed(ctx, user.Email, ip, &user.ID, err)
+		}
+		return nil, err
+	}
+
+	// A challenge is exchanged once, a wrong code leaves it for another try
+	if err = s.consumeMFAChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	if err = s.loginGuardService.RecordSuccess(ctx, user.Email, ip, user.ID); err != nil {
+		return nil, err
+	}
+
+	return issueAccessToken(user, []string{AuthMethodPassword, AuthMethodMFA})
+}
+
+// consumeMFAChallenge marks the challenge used until it expires, ErrInvalidMFAToken when it already was
+func (s authService) consumeMFAChallenge(ctx context.Context, challenge *mfaChallenge) error {
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return ErrInvalidMFAToken
+	}
+
+	fresh, err := s.redis.SetNX(ctx, RedisKey("mfa", "challenge", challenge.ID), 1, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFAToken
+	}
+
+	return nil
+}
+
+// failed records a failed attempt and returns the error for the caller
+func (s authService) failed(ctx context.Context, email string, ip string, userID *uint, err error) error {
+	if recordErr := s.loginGuardService.RecordFailure(ctx, email, ip, userID); recordErr != nil {
+		return recordErr
+	}
+
+	return err
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+)
+
+type (
+	// LoginGuardService protects the login against brute-force and credential stuffing
+	LoginGuardService interface {
+		// Check returns a *LoginThrottledError while the email or IP has to wait
+		Check(ctx context.Context, email string, ip string) error
+		RecordFailure(ctx context.Context, email string, ip string, userID *uint) error
+		RecordSuccess(ctx context.Context, email string, ip string, userID uint) error
+		// Unlock lifts the lockout and delay of the user account
+		Unlock(ctx context.Context, userID int, actor string) error
+		GetSecurityEvents(ctx context.Context, paginate database.Pagination, search string) (*database.Page[models.SecurityEvent], error)
+	}
+	// LoginThrottledError is returned for locked accounts and throttled IPs alike,
+	// so the response does not reveal whether an email is registered
+	LoginThrottledError struct {
+		RetryAfter time.Duration
+	}
+)
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %d seconds", int(e.RetryAfter.Seconds()))
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/repositories"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	loginGuardService struct {
+		userRepository          repositories.UserRepository
+		securityEventRepository repositories.SecurityEventRepository
+		redis                   redis.UniversalClient
+	}
+)
+
+func NewLoginGuardService(
+	userRepo repositories.UserRepository,
+	securityEventRepo repositories.SecurityEventRepository,
+	redisClient redis.UniversalClient,
+) LoginGuardService {
+	return &loginGuardService{
+		userRepository:          userRepo,
+		securityEventRepository: securityEventRepo,
+		redis:                   redisClient,
+	}
+}
+
+func (s loginGuardService) Check(ctx context.Context, email string, ip string) error {
+	email = normalizeEmail(email)
+
+	var waits []*redis.DurationCmd
+	_, err := s.redis.Pipelined(ctx, func(p redis.Pipeliner) error {
+		waits = append(waits,
+			p.PTTL(ctx, loginKey("lock", "account", email)),
+			p.PTTL(ctx, loginKey("delay", "account", email)),
+			p.PTTL(ctx, loginKey("lock", "ip", ip)),
+		)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// A missing key has a negative TTL
+	var retryAfter time.Duration
+	for _, wait := range waits {
+		if wait.Val() > retryAfter {
+			retryAfter = wait.Val()
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginThrottledError{RetryAfter: retryAfter.Round(time.Second) + time.Second}
+	}
+
+	return nil
+}
+
+func (s loginGuardService) RecordFailure(ctx context.Context, email string, ip string, userID *uint) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RecordLoginFailureService", trace.WithAttributes(attribute.String("service", "RecordLoginFailure")))
+	defer childSpan.End()
+
+	email = normalizeEmail(email)
+	window := time.Duration(config.AppConfig.LoginAttemptWindowMinutes) * time.Minute
+
+	// Counters live until the window passes without a failure
+	var accountFailures, ipFailures *redis.IntCmd
+	_, err := s.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		accountFailures = p.Incr(ctx, loginKey("failures", "account", email))
+		p.Expire(ctx, loginKey("failures", "account", email), window)
+		ipFailures = p.Incr(ctx, loginKey("failures", "ip", ip))
+		p.Expire(ctx, loginKey("failures", "ip", ip), window)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, &models.SecurityEvent{Event: models.SecurityEventLoginFailed, UserID: userID, Email: email, IP: ip})
+
+	failures := int(accountFailures.Val())
+	switch {
+	case failures >= config.AppConfig.LoginMaxAttempts:
+		// Temporary lockout
+		lockout := time.Duration(config.AppConfig.LoginLockoutMinutes) * time.Minute
+		if err = s.redis.Set(ctx, loginKey("lock", "account", email), 1, lockout).Err(); err != nil {
+			return err
+		}
+		if failures == config.AppConfig.LoginMaxAttempts {
+			s.audit(ctx, &models.SecurityEvent{Event: models.SecurityEventAccountLocked, UserID: userID, Email: email, IP: ip})
+		}
+	case failures >= config.AppConfig.LoginDelayAfter:
+		// Progressive delay, doubling with every failure
+		delay := time.Duration(math.Pow(2, float64(failures-config.AppConfig.LoginDelayAfter))) * time.Second
+		if maxDelay := time.Duration(config.AppConfig.LoginMaxDelaySeconds) * time.Second; delay > maxDelay {
+			delay = maxDelay
+		}
+		if err = s.redis.Set(ctx, loginKey("delay", "account", email), 1, delay).Err(); err != nil {
+			return err
+		}
+	}
+
+	// Too many failures from one IP across any accounts
+	if int(ipFailures.Val()) >= config.AppConfig.LoginIPMaxAttempts {
+		if err = s.redis.Set(ctx, loginKey("lock", "ip", ip), 1, window).Err(); err != nil {
+			return err
+		}
+		if int(ipFailures.Val()) == config.AppConfig.LoginIPMaxAttempts {
+			s.audit(ctx, &models.SecurityEvent{Event: models.SecurityEventIPBlocked, Email: email, IP: ip})
+		}
+	}
+
+	return nil
+}
+
+func (s loginGuardService) RecordSuccess(ctx context.Context, email string, ip string, userID uint) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RecordLoginSuccessService", trace.WithAttributes(attribute.String("service", "RecordLoginSuccess")))
+	defer childSpan.End()
+
+	email = normalizeEmail(email)
+
+	if err := s.redis.Del(ctx, loginKey("failures", "account", email), loginKey("delay", "account", email)).Err(); err != nil {
+		return err
+	}
+
+	s.audit(ctx, &models.SecurityEvent{Event: models.SecurityEventLoginSucceeded, UserID: &userID, Email: email, IP: ip})
+
+	return nil
+}
+
+func (s loginGuardService) Unlock(ctx context.Context, userID int, actor string) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "UnlockLoginService", trace.WithAttributes(attribute.String("service", "UnlockLogin")))
+	defer childSpan.End()
+
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	email := normalizeEmail(user.Email)
+	if err = s.redis.Del(
+		ctx,
+		loginKey("lock", "account", email),
+		loginKey("delay", "account", email),
+		loginKey("failures", "account", email),
+	).Err(); err != nil {
+		return err
+	}
+
+	s.audit(ctx, &models.SecurityEvent{Event: models.SecurityEventAccountUnlocked, UserID: &user.ID, Email: email, Actor: actor})
+
+	return nil
+}
+
+func (s loginGuardService) GetSecurityEvents(ctx context.Context, paginate database.Pagination, search string) (*database.Page[models.SecurityEvent], error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "GetSecurityEventsService", trace.WithAttributes(attribute.String("service", "GetSecurityEvents")))
+	result, err := s.securityEventRepository.GetSecurityEventPaginate(ctx, paginate, search)
+	childSpan.End()
+
+	return result, err
+}
+
+// audit stores a security event, a failure to write it must not block the login
+func (s loginGuardService) audit(ctx context.Context, securityEvent *models.SecurityEvent) {
+	if err := s.securityEventRepository.CreateSecurityEvent(ctx, securityEvent); err != nil {
+		utils.HandleErrors(err)
+	}
+}
+
+func loginKey(kind string, scope string, value string) string {
+	return RedisKey("login", kind, scope, value)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+)
+
+type (
+	MFAService interface {
+		// EnrollTOTP creates a new pending TOTP secret, it is enabled by ConfirmTOTP
+		EnrollTOTP(ctx context.Context, userID int) (*TOTPEnrollment, error)
+		// ConfirmTOTP enables MFA and returns the recovery codes, they are only shown once
+		ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error)
+		DisableMFA(ctx context.Context, userID int, code string) error
+		RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+		// VerifyCode accepts a TOTP code or an unused recovery code of the user
+		VerifyCode(ctx context.Context, user *models.User, code string, recoveryCode string) error
+	}
+	MFACodeDto struct {
+		Code string `json:"code" form:"code" validate:"required,len=6,numeric"`
+	}
+	TOTPEnrollment struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled")
+	ErrMFANotEnrolled    = errors.New("mfa is not enrolled")
+	ErrInvalidMFACode    = errors.New("invalid mfa code")
+)
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/secretbox"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/totp"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/repositories"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const recoveryCodeCount = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type (
+	mfaService struct {
+		userRepository             repositories.UserRepository
+		userRecoveryCodeRepository repositories.UserRecoveryCodeRepository
+		transactor                 database.Transactor
+		redis                      redis.UniversalClient
+	}
+)
+
+func NewMFAService(
+	userRepo repositories.UserRepository,
+	userRecoveryCodeRepo repositories.UserRecoveryCodeRepository,
+	transactor database.Transactor,
+	redisClient redis.UniversalClient,
+) MFAService {
+	return &mfaService{
+		userRepository:             userRepo,
+		userRecoveryCodeRepository: userRecoveryCodeRepo,
+		transactor:                 transactor,
+		redis:                      redisClient,
+	}
+}
+
+func (s mfaService) EnrollTOTP(ctx context.Context, userID int) (*TOTPEnrollment, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "EnrollTOTPService", trace.WithAttributes(attribute.String("service", "EnrollTOTP")))
+	defer childSpan.End()
+
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret is stored encrypted and stays pending until confirmed with a code
+	sealedSecret, err := secretbox.Seal(config.AppConfig.MFAEncryptionKey, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.userRepository.UpdateUserMFA(ctx, userID, false, sealedSecret, nil); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(config.AppConfig.AppName, user.Email, secret),
+	}, nil
+}
+
+func (s mfaService) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "ConfirmTOTPService", trace.WithAttributes(attribute.String("service", "ConfirmTOTP")))
+	defer childSpan.End()
+
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err = s.verifyTOTP(ctx, &user, code); err != nil {
+		return nil, err
+	}
+
+	// MFA is only enabled together with its recovery codes
+	var codes []string
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		if err := s.userRepository.UpdateUserMFA(ctx, userID, true, user.MFASecret, &now); err != nil {
+			return err
+		}
+
+		codes, err = s.replaceRecoveryCodes(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s mfaService) DisableMFA(ctx context.Context, userID int, code string) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "DisableMFAService", trace.WithAttributes(attribute.String("service", "DisableMFA")))
+	defer childSpan.End()
+
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnrolled
+	}
+
+	if err = s.verifyTOTP(ctx, &user, code); err != nil {
+		return err
+	}
+
+	return s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepository.UpdateUserMFA(ctx, userID, false, "", nil); err != nil {
+			return err
+		}
+
+		return s.userRecoveryCodeRepository.DeleteRecoveryCodes(ctx, user.ID)
+	})
+}
+
+func (s mfaService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RegenerateRecoveryCodesService", trace.WithAttributes(attribute.String("service", "RegenerateRecoveryCodes")))
+	defer childSpan.End()
+
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err = s.verifyTOTP(ctx, &user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, user.ID)
+}
+
+func (s mfaService) VerifyCode(ctx context.Context, user *models.User, code string, recoveryCode string) error {
+	if !user.MFAEnabled {
+		return ErrMFANotEnrolled
+	}
+
+	if code != "" {
+		return s.verifyTOTP(ctx, user, code)
+	}
+
+	used, err := s.userRecoveryCodeRepository.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(recoveryCode))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// verifyTOTP checks the code and rejects a replay of a code that was already accepted
+func (s mfaService) verifyTOTP(ctx context.Context, user *models.User, code string) error {
+	secret, err := secretbox.Open(config.AppConfig.MFAEncryptionKey, user.MFASecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	key := RedisKey("mfa", "used", "{"+strconv.Itoa(int(user.ID))+"}", strconv.FormatUint(step, 10))
+	fresh, err := s.redis.SetNX(ctx, key, 1, (2*totp.Skew+1)*totp.Period).Result()
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (s mfaService) replaceRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	codeHashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))
+		code := encoded[:8] + "-" + encoded[8:]
+
+		codes = append(codes, code)
+		codeHashes = append(codeHashes, hashRecoveryCode(code))
+	}
+
+	if err := s.userRecoveryCodeRepository.ReplaceRecoveryCodes(ctx, userID, codeHashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes the user may type differently
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
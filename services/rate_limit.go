@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindowScript estimates the hits of the last window from the counts of
+// the current and previous fixed windows, and only counts allowed hits so that
+// throttled clients recover once they slow down.
+// KEYS[1] is the current window, KEYS[2] the previous one.
+// ARGV[1] is the limit, ARGV[2] the weight of the previous window and ARGV[3]
+// the window in milliseconds.
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+local count = math.floor(previous * tonumber(ARGV[2])) + current
+if count >= tonumber(ARGV[1]) then
+	return {0, count}
+end
+if redis.call("INCR", KEYS[1]) == 1 then
+	redis.call("PEXPIRE", KEYS[1], tonumber(ARGV[3]) * 2)
+end
+return {1, count + 1}
+`)
+
+// incrementWindow counts a hit in the current fixed window of the key and
+// returns the count so far with the time left until the window resets
+func incrementWindow(ctx context.Context, client redis.UniversalClient, key string, window time.Duration) (int, time.Duration, error) {
+	now := time.Now()
+	bucket := now.Unix() / int64(window.Seconds())
+	bucketKey := key + ":" + strconv.FormatInt(bucket, 10)
+
+	var incr *redis.IntCmd
+	_, err := client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		incr = p.Incr(ctx, bucketKey)
+		p.Expire(ctx, bucketKey, window)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	reset := window - time.Duration(now.Unix()%int64(window.Seconds()))*time.Second
+
+	return int(incr.Val()), reset, nil
+}
+
+// slidingWindow consumes a hit from the sliding window limit of the key. Unlike a
+// fixed window it does not allow twice the limit around a window boundary.
+// The key is wrapped in a hash tag so both windows live on the same cluster slot.
+func slidingWindow(ctx context.Context, client redis.UniversalClient, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	now := time.Now()
+	bucket := now.UnixMilli() / window.Milliseconds()
+	elapsed := time.Duration(now.UnixMilli()%window.Milliseconds()) * time.Millisecond
+	weight := 1 - float64(elapsed)/float64(window)
+
+	keys := []string{
+		"{" + key + "}:" + strconv.FormatInt(bucket, 10),
+		"{" + key + "}:" + strconv.FormatInt(bucket-1, 10),
+	}
+	values, err := slidingWindowScript.Run(ctx, client, keys,
+		limit, strconv.FormatFloat(weight, 'f', 6, 64), window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := limit - int(values[1])
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &RateLimitResult{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     window - elapsed,
+		Window:    window,
+	}, nil
+}
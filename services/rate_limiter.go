@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// RateLimiterService shares request limits between all instances through Redis
+	RateLimiterService interface {
+		// Allow consumes one request of the identity from the named sliding window limit
+		Allow(ctx context.Context, name string, identity string, limit int, window time.Duration) (*RateLimitResult, error)
+	}
+	RateLimitResult struct {
+		Allowed   bool
+		Limit     int
+		Remaining int
+		// Reset is the time left until the current window ends
+		Reset  time.Duration
+		Window time.Duration
+	}
+)
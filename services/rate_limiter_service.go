@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type (
+	rateLimiterService struct {
+		redis redis.UniversalClient
+	}
+)
+
+func NewRateLimiterService(redisClient redis.UniversalClient) RateLimiterService {
+	return &rateLimiterService{
+		redis: redisClient,
+	}
+}
+
+func (s rateLimiterService) Allow(ctx context.Context, name string, identity string, limit int, window time.Duration) (*RateLimitResult, error) {
+	return slidingWindow(ctx, s.redis, RedisKey("rate", name, identity), limit, window)
+}
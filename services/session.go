@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type (
+	SessionService interface {
+		// Verify rejects revoked tokens and records the session, in a single Redis round trip
+		Verify(ctx context.Context, session *Session) error
+		GetSessions(ctx context.Context, subject string) ([]Session, error)
+		RevokeSession(ctx context.Context, subject string, id string) error
+		// RevokeOtherSessions revokes every session of the subject except the current one
+		RevokeOtherSessions(ctx context.Context, subject string, currentID string) error
+		// RevokeAllSessions signs the subject out everywhere
+		RevokeAllSessions(ctx context.Context, subject string) error
+	}
+	// Session is an access token in use, identified by its jti claim
+	Session struct {
+		ID         string    `json:"id"`
+		Subject    string    `json:"subject"`
+		IP         string    `json:"ip"`
+		UserAgent  string    `json:"user_agent"`
+		IssuedAt   time.Time `json:"issued_at"`
+		ExpiresAt  time.Time `json:"expires_at"`
+		LastSeenAt time.Time `json:"last_seen_at"`
+		Current    bool      `json:"current"`
+	}
+)
+
+var (
+	ErrTokenRevoked    = errors.New("token has been revoked")
+	ErrSessionNotFound = errors.New("session not found")
+)
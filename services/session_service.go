@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// verifySessionScript checks the denylist and the subject wide revocation,
+// then records the session only if the token is still valid. Tokens issued
+// in the millisecond of the revocation or later stay valid.
+//
+// KEYS[1] jti denylist key, KEYS[2] revoked before key, KEYS[3] sessions hash
+// ARGV[1] issued at in milliseconds, ARGV[2] jti, ARGV[3] session JSON, ARGV[4] sessions TTL
+var verifySessionScript = redis.NewScript(`
+if ARGV[2] ~= '' and redis.call('EXISTS', KEYS[1]) == 1 then
+	return 1
+end
+local before = redis.call('GET', KEYS[2])
+if before and tonumber(ARGV[1]) < tonumber(before) then
+	return 1
+end
+if ARGV[2] ~= '' then
+	redis.call('HSET', KEYS[3], ARGV[2], ARGV[3])
+	redis.call('EXPIRE', KEYS[3], ARGV[4])
+end
+return 0
+`)
+
+type (
+	// sessionService keeps its state in Redis directly rather than through
+	// cache.Client. Verify must check the denylist and the revocation and
+	// record the session in one script over three keys of the subject's slot,
+	// which the cache backend cannot run. Sessions must also survive cache
+	// flushes and purges and the memory cache driver.
+	sessionService struct {
+		redis redis.UniversalClient
+	}
+)
+
+func NewSessionService(redisClient redis.UniversalClient) SessionService {
+	return &sessionService{
+		redis: redisClient,
+	}
+}
+
+func (s sessionService) Verify(ctx context.Context, session *Session) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := verifySessionScript.Run(
+		ctx,
+		s.redis,
+		[]string{
+			denylistKey(session.Subject, session.ID),
+			revokedBeforeKey(session.Subject),
+			sessionsKey(session.Subject),
+		},
+		session.IssuedAt.UnixMilli(),
+		session.ID,
+		string(value),
+		int(maxTokenLifetime().Seconds()),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if revoked == 1 {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+func (s sessionService) GetSessions(ctx context.Context, subject string) ([]Session, error) {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "GetSessionsService", trace.WithAttributes(attribute.String("service", "GetSessions")))
+	defer childSpan.End()
+
+	values, err := s.redis.HGetAll(ctx, sessionsKey(subject)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now      = time.Now()
+		sessions = make([]Session, 0, len(values))
+		expired  []string
+	)
+	for id, value := range values {
+		var session Session
+		if err := json.Unmarshal([]byte(value), &session); err != nil {
+			expired = append(expired, id)
+			continue
+		}
+		if !session.ExpiresAt.IsZero() && session.ExpiresAt.Before(now) {
+			expired = append(expired, id)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	// Prune sessions of expired tokens
+	if len(expired) > 0 {
+		if err := s.redis.HDel(ctx, sessionsKey(subject), expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (s sessionService) RevokeSession(ctx context.Context, subject string, id string) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RevokeSessionService", trace.WithAttributes(attribute.String("service", "RevokeSession")))
+	defer childSpan.End()
+
+	value, err := s.redis.HGet(ctx, sessionsKey(subject), id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	var session Session
+	if err = json.Unmarshal([]byte(value), &session); err != nil {
+		return err
+	}
+
+	return s.revoke(ctx, session)
+}
+
+func (s sessionService) RevokeOtherSessions(ctx context.Context, subject string, currentID string) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RevokeOtherSessionsService", trace.WithAttributes(attribute.String("service", "RevokeOtherSessions")))
+	defer childSpan.End()
+
+	sessions, err := s.GetSessions(ctx, subject)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == currentID {
+			continue
+		}
+		if err = s.revoke(ctx, session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s sessionService) RevokeAllSessions(ctx context.Context, subject string) error {
+	ctx, childSpan := tracing.Tracer.Start(ctx, "RevokeAllSessionsService", trace.WithAttributes(attribute.String("service", "RevokeAllSessions")))
+	defer childSpan.End()
+
+	// Every token issued up to now is rejected until the longest lived one has expired
+	_, err := s.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, revokedBeforeKey(subject), time.Now().UnixMilli(), maxTokenLifetime())
+		p.Del(ctx, sessionsKey(subject))
+		return nil
+	})
+
+	return err
+}
+
+// revoke puts the token on the denylist until it would expire anyway
+func (s sessionService) revoke(ctx context.Context, session Session) error {
+	ttl := maxTokenLifetime()
+	if !session.ExpiresAt.IsZero() {
+		ttl = time.Until(session.ExpiresAt)
+	}
+
+	_, err := s.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		if ttl > 0 {
+			p.Set(ctx, denylistKey(session.Subject, session.ID), 1, ttl)
+		}
+		p.HDel(ctx, sessionsKey(session.Subject), session.ID)
+		return nil
+	})
+
+	return err
+}
+
+func maxTokenLifetime() time.Duration {
+	return time.Duration(config.AppConfig.JWTMaxLifetimeMinutes) * time.Minute
+}
+
+// Keys of a subject share a hash tag so the verify script works on Redis Cluster
+func denylistKey(subject string, id string) string {
+	return RedisKey("jwt", "denylist", "{"+subject+"}", id)
+}
+
+func revokedBeforeKey(subject string) string {
+	return RedisKey("jwt", "revoked_before", "{"+subject+"}")
+}
+
+func sessionsKey(subject string) string {
+	return RedisKey("sessions", "{"+subject+"}")
+}
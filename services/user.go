@@ -4,19 +4,24 @@ import (
 	"context"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
 )
 
 type (
 	UserService interface {
-		GetUsers(ctx context.Context, paginate database.Pagination, search string) (*database.Pagination, error)
+		GetUsers(ctx context.Context, paginate database.Pagination, search string) (*database.Page[models.User], error)
 		GetUser(ctx context.Context, id int) (map[string]interface{}, error)
 		CreateUser(ctx context.Context, userDto *UserDto) error
 		UpdateUser(ctx context.Context, id int, userDto *UserDto) error
 		DeleteUser(ctx context.Context, id int) error
 	}
 	UserDto struct {
-		FirstName string `json:"first_name" form:"first_name" query:"first_name" validate:"required,max:50"`
-		LastName  string `json:"last_name" form:"last_name" query:"last_name" validate:"required,max:50"`
-		Email     string `json:"email" form:"email" query:"email" validate:"required,email,max:100"`
+		FirstName string `json:"first_name" form:"first_name" query:"first_name" validate:"required,max=50"`
+		LastName  string `json:"last_name" form:"last_name" query:"last_name" validate:"required,max=50"`
+		Email     string `json:"email" form:"email" query:"email" validate:"required,email,max=100"`
+		Password  string `json:"password" form:"password" validate:"omitempty,min=8,max=72"`
+		// Phone is stored with its digits only
+		Phone      string `json:"phone" form:"phone" validate:"omitempty,max=30"`
+		MemberCode string `json:"member_code" form:"member_code" validate:"omitempty,alphanum,max=30"`
 	}
 )
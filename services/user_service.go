@@ -2,13 +2,16 @@ package services
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/database"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/models"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/repositories"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/utils"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type (
@@ -25,14 +28,48 @@ func NewUserService(
 	}
 }
 
-func (s userService) GetUsers(ctx context.Context, paginate database.Pagination, search string) (*database.Pagination, error) {
+func (s userService) GetUsers(ctx context.Context, paginate database.Pagination, search string) (*database.Page[models.User], error) {
 	ctx, childSpan := tracing.Tracer.Start(ctx, "GetUsersService", trace.WithAttributes(attribute.String("service", "GetUsers")))
 	result, err := s.userRepository.GetUserPaginate(ctx, paginate, search)
+	if err == nil && search != "" {
+		terms := strings.Fields(search)
+		for i := range result.Data {
+			result.Data[i].Highlights = highlightUser(result.Data[i], terms)
+		}
+	}
 	childSpan.End()
 
 	return result, err
 }
 
+// highlightUser marks the search terms in the fields of the user which matched
+func highlightUser(user models.User, terms []string) map[string]string {
+	fields := map[string]string{
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"email":      user.Email,
+	}
+	if user.MemberCode != nil {
+		fields["member_code"] = *user.MemberCode
+	}
+
+	highlights := make(map[string]string)
+	for field, value := range fields {
+		if highlighted, ok := utils.Highlight(value, terms); ok {
+			highlights[field] = highlighted
+		}
+	}
+
+	// Phone numbers are matched by their digits, whatever the separators
+	if user.Phone != nil {
+		if highlighted, ok := utils.Highlight(*user.Phone, []string{utils.Digits(strings.Join(terms, ""))}); ok {
+			highlights["phone"] = highlighted
+		}
+	}
+
+	return highlights
+}
+
 func (s userService) GetUser(ctx context.Context, id int) (map[string]interface{}, error) {
 	ctx, childSpan := tracing.Tracer.Start(ctx, "GetUserService", trace.WithAttributes(attribute.String("service", "GetUser")))
 	user, err := s.userRepository.GetUserByID(ctx, id)
@@ -48,6 +85,17 @@ func (s userService) CreateUser(ctx context.Context, userDto *UserDto) error {
 	user.FirstName = userDto.FirstName
 	user.LastName = userDto.LastName
 	user.Email = userDto.Email
+	user.Phone = optionalString(utils.Digits(userDto.Phone))
+	user.MemberCode = optionalString(strings.ToUpper(userDto.MemberCode))
+
+	// Users without a password can only sign in through the OAuth provider
+	if userDto.Password != "" {
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(userDto.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user.PasswordHash = string(passwordHash)
+	}
 
 	childSpan.End()
 
@@ -61,6 +109,8 @@ func (s userService) UpdateUser(ctx context.Context, id int, userDto *UserDto) e
 	user.FirstName = userDto.FirstName
 	user.LastName = userDto.LastName
 	user.Email = userDto.Email
+	user.Phone = optionalString(utils.Digits(userDto.Phone))
+	user.MemberCode = optionalString(strings.ToUpper(userDto.MemberCode))
 
 	childSpan.End()
 
@@ -74,3 +124,12 @@ func (s userService) DeleteUser(ctx context.Context, id int) error {
 
 	return err
 }
+
+// optionalString stores empty values as NULL, so unique columns allow many of them
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Digits keeps the digits of a value, phone numbers are stored and matched this way
+func Digits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+// Highlight escapes the value for HTML and wraps the case-insensitive matches
+// of the terms in <mark>, it reports whether any term matched
+func Highlight(value string, terms []string) (string, bool) {
+	// Mark the matched bytes, matches of different terms may overlap
+	marked := make([]bool, len(value))
+	matched := false
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		for i := range value {
+			if i+len(term) <= len(value) && strings.EqualFold(value[i:i+len(term)], term) {
+				for j := i; j < i+len(term); j++ {
+					marked[j] = true
+				}
+				matched = true
+			}
+		}
+	}
+	if !matched {
+		return "", false
+	}
+
+	var highlighted strings.Builder
+	for start := 0; start < len(value); {
+		end := start
+		for end < len(value) && marked[end] == marked[start] {
+			end++
+		}
+
+		if marked[start] {
+			highlighted.WriteString("<mark>" + html.EscapeString(value[start:end]) + "</mark>")
+		} else {
+			highlighted.WriteString(html.EscapeString(value[start:end]))
+		}
+		start = end
+	}
+
+	return highlighted.String(), true
+}